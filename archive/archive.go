@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	handler "github.com/noctisatrae/farseer/handlers"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+)
+
+const (
+	// One JSON message per line, encoded with protojson.
+	FormatNDJSON = "ndjson"
+	// Length-delimited protobuf messages, readable with protodelim.UnmarshalFrom.
+	FormatProtodelim = "protodelim"
+
+	CompressionZstd = "zstd"
+	CompressionNone = "none"
+)
+
+// The [handlers.archive] table of config.toml.
+//
+// ```toml
+// [handlers.archive]
+// Directory = "archive" # optional: where the archives & their manifest are written
+// Format = "ndjson" # optional: ndjson or protodelim
+// Compression = "zstd" # optional: zstd or none
+// MaxSize = 256 # optional: an archive is closed once it holds this many MB, before compression
+// MaxAge = 3600 # optional: an archive is closed after this many seconds
+// ```
+type Config struct {
+	Directory   string
+	Format      string
+	Compression string
+	MaxSize     uint
+	MaxAge      uint
+}
+
+// Appends every message to rotating files, a raw archive independent of any database. An archive is written with a
+// .partial suffix, then renamed & added to manifest.ndjson when it's closed.
+type Archive struct {
+	config Config
+	ll     log.Logger
+	// replaced in the tests
+	now func() time.Time
+
+	// the archive is also closed in the background when it gets too old
+	mu      sync.Mutex
+	current *archiveFile
+	stopCh  chan struct{}
+	done    chan struct{}
+}
+
+func (a *Archive) Name() string {
+	return "Archive"
+}
+
+func (a *Archive) Configure(raw []byte) error {
+	config := Config{
+		Directory:   "archive",
+		Format:      FormatNDJSON,
+		Compression: CompressionZstd,
+		MaxSize:     256,
+		MaxAge:      3600,
+	}
+	if err := handler.Decode(raw, &config); err != nil {
+		return err
+	}
+	if config.Format != FormatNDJSON && config.Format != FormatProtodelim {
+		return fmt.Errorf("invalid Format %q, expected %q or %q", config.Format, FormatNDJSON, FormatProtodelim)
+	}
+	if config.Compression != CompressionZstd && config.Compression != CompressionNone {
+		return fmt.Errorf("invalid Compression %q, expected %q or %q", config.Compression, CompressionZstd, CompressionNone)
+	}
+	if config.MaxSize == 0 || config.MaxAge == 0 {
+		return fmt.Errorf("MaxSize & MaxAge can't be 0")
+	}
+
+	a.config = config
+	return nil
+}
+
+// Creates the directory & closes the archives left behind by a crash.
+func (a *Archive) Init(ctx context.Context) error {
+	a.ll = *log.WithPrefix(a.Name())
+	if a.now == nil {
+		a.now = time.Now
+	}
+
+	if err := os.MkdirAll(a.config.Directory, 0o755); err != nil {
+		return err
+	}
+	recovered, err := recoverPartials(a.config.Directory)
+	if err != nil {
+		return err
+	}
+	for _, entry := range recovered {
+		a.ll.Warn("Recovered an archive left behind by a crash! |", "File", entry.File)
+	}
+
+	a.stopCh = make(chan struct{})
+	a.done = make(chan struct{})
+	go a.rotateOld()
+
+	return nil
+}
+
+// Closes the current archive.
+func (a *Archive) Close(ctx context.Context) error {
+	if a.stopCh == nil {
+		return nil
+	}
+	close(a.stopCh)
+	<-a.done
+	a.stopCh = nil
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.rotate()
+}
+
+func (a *Archive) HandleMessage(ctx context.Context, m *protos.Message, meta handler.MessageMeta) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.current != nil && a.tooOld() {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+	if a.current == nil {
+		current, err := openArchive(a.config.Directory, a.config.Format, a.config.Compression, a.now())
+		if err != nil {
+			return err
+		}
+		a.current = current
+	}
+
+	if err := a.current.Write(m); err != nil {
+		return err
+	}
+	if a.current.entry.Bytes >= uint64(a.config.MaxSize)<<20 {
+		return a.rotate()
+	}
+	return nil
+}
+
+// Closes the current archive & adds it to the manifest. The next message opens a new one.
+func (a *Archive) rotate() error {
+	if a.current == nil {
+		return nil
+	}
+
+	entry, err := a.current.Close(a.now())
+	a.current = nil
+	if err != nil {
+		return err
+	}
+	a.ll.Info("Archived! |", "File", entry.File, "Messages", entry.Messages)
+	return appendManifest(a.config.Directory, entry)
+}
+
+func (a *Archive) tooOld() bool {
+	return a.now().Sub(a.current.entry.OpenedAt) >= time.Duration(a.config.MaxAge)*time.Second
+}
+
+// Closes the archive once it's MaxAge old, even if no message comes to trigger it.
+func (a *Archive) rotateOld() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+			a.mu.Lock()
+			if a.current != nil && a.tooOld() {
+				if err := a.rotate(); err != nil {
+					a.ll.Error("Couldn't close the archive! |", "Error", err)
+				}
+			}
+			a.mu.Unlock()
+		}
+	}
+}
+
+// Every type of message, the relay only sends the ones matching the Filter of the plugin.
+func (a *Archive) MessageTypes() []protos.MessageType {
+	return handler.AllMessageTypes()
+}
+
+// Served by main, or looked up by the relay when the plugin is built as a shared library.
+var Plugin handler.Plugin = &Archive{}
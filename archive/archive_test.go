@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	handler "github.com/noctisatrae/farseer/handlers"
+	"github.com/noctisatrae/farseer/handlers/plugintest"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Configures & initializes the plugin in a temporary directory, with a clock moving forward by a second every call.
+func initPlugin(t *testing.T, config string) (*Archive, *time.Time) {
+	log.SetLevel(log.DebugLevel)
+	directory := t.TempDir()
+
+	clock := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	a := &Archive{now: func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}}
+	plugintest.Start(t, a, "Directory = '"+directory+"'\n"+config)
+
+	return a, &clock
+}
+
+func cast(hash byte, text string) *protos.Message {
+	return &protos.Message{
+		Hash: []byte{hash},
+		Data: &protos.MessageData{
+			Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD,
+			Fid:  10626,
+			Body: &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: text}},
+		},
+	}
+}
+
+// Reads back the messages of an archive.
+func readArchive(t *testing.T, directory string, entry ManifestEntry) []*protos.Message {
+	f, err := os.Open(filepath.Join(directory, entry.File))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if entry.Compression == CompressionZstd {
+		decoder, err := zstd.NewReader(f)
+		assert.NoError(t, err)
+		defer decoder.Close()
+		r = decoder
+	}
+
+	messages := []*protos.Message{}
+	if entry.Format == FormatNDJSON {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(nil, 4<<20)
+		for scanner.Scan() {
+			m := &protos.Message{}
+			assert.NoError(t, protojson.Unmarshal(scanner.Bytes(), m))
+			messages = append(messages, m)
+		}
+		assert.NoError(t, scanner.Err())
+	} else {
+		reader := bufio.NewReader(r)
+		for {
+			m := &protos.Message{}
+			err := protodelim.UnmarshalFrom(reader, m)
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+func TestConfigure(t *testing.T) {
+	a := &Archive{}
+	assert.Error(t, a.Configure([]byte("Format = 'csv'")))
+	assert.Error(t, a.Configure([]byte("Compression = 'gzip'")))
+	assert.Error(t, a.Configure([]byte("MaxAge = 0")))
+	assert.NoError(t, a.Configure([]byte("")))
+	assert.Equal(t, "archive", a.config.Directory)
+}
+
+// Is an archive closed once it's too big, with the range of its hashes in the manifest?
+func TestRotateBySize(t *testing.T) {
+	a, _ := initPlugin(t, "MaxSize = 1")
+	ctx := context.Background()
+
+	big := strings.Repeat("gm", 300_000)
+	assert.NoError(t, a.HandleMessage(ctx, cast(0x20, big), handler.MessageMeta{}))
+	assert.NoError(t, a.HandleMessage(ctx, cast(0x10, big), handler.MessageMeta{}))
+	assert.NoError(t, a.HandleMessage(ctx, cast(0x30, "gn"), handler.MessageMeta{}))
+	assert.NoError(t, a.Close(ctx))
+
+	entries, err := ReadManifest(a.config.Directory)
+	assert.NoError(t, err)
+	if !assert.Len(t, entries, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, uint64(2), entries[0].Messages)
+	assert.Equal(t, "0x20", entries[0].FirstHash)
+	assert.Equal(t, "0x10", entries[0].LastHash)
+	assert.Equal(t, "0x10", entries[0].MinHash)
+	assert.Equal(t, "0x20", entries[0].MaxHash)
+	assert.True(t, strings.HasSuffix(entries[0].File, ".ndjson.zst"))
+
+	messages := readArchive(t, a.config.Directory, entries[0])
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, big, messages[1].Data.GetCastAddBody().Text)
+	}
+	messages = readArchive(t, a.config.Directory, entries[1])
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, "gn", messages[0].Data.GetCastAddBody().Text)
+	}
+}
+
+// Is an archive closed once it's too old?
+func TestRotateByAge(t *testing.T) {
+	a, clock := initPlugin(t, "MaxAge = 60\nFormat = 'protodelim'\nCompression = 'none'")
+	ctx := context.Background()
+
+	assert.NoError(t, a.HandleMessage(ctx, cast(1, "gm"), handler.MessageMeta{}))
+	assert.NoError(t, a.HandleMessage(ctx, cast(2, "gm"), handler.MessageMeta{}))
+	// the clock is only read while holding the lock
+	a.mu.Lock()
+	*clock = clock.Add(time.Minute)
+	a.mu.Unlock()
+	assert.NoError(t, a.HandleMessage(ctx, cast(3, "gn"), handler.MessageMeta{}))
+
+	entries, err := ReadManifest(a.config.Directory)
+	assert.NoError(t, err)
+	if !assert.Len(t, entries, 1) {
+		t.FailNow()
+	}
+	assert.True(t, strings.HasSuffix(entries[0].File, ".protodelim"))
+	assert.Len(t, readArchive(t, a.config.Directory, entries[0]), 2)
+
+	// the archive being written isn't complete
+	partials, err := filepath.Glob(filepath.Join(a.config.Directory, "*"+partialSuffix))
+	assert.NoError(t, err)
+	assert.Len(t, partials, 1)
+}
+
+// Is an archive left behind by a crash recovered when the plugin starts again?
+func TestRecover(t *testing.T) {
+	directory := t.TempDir()
+	partial := filepath.Join(directory, "farseer-20240601T000000.000000000Z.ndjson.zst"+partialSuffix)
+	assert.NoError(t, os.WriteFile(partial, []byte{}, 0o644))
+
+	a := &Archive{}
+	assert.NoError(t, a.Configure([]byte("Directory = '"+directory+"'")))
+	assert.NoError(t, a.Init(context.Background()))
+	assert.NoError(t, a.Close(context.Background()))
+
+	entries, err := ReadManifest(directory)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.True(t, entries[0].Recovered)
+		assert.Equal(t, FormatNDJSON, entries[0].Format)
+		assert.Equal(t, CompressionZstd, entries[0].Compression)
+	}
+	_, err = os.Stat(strings.TrimSuffix(partial, partialSuffix))
+	assert.NoError(t, err)
+}
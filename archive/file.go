@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	protos "github.com/noctisatrae/farseer/protos"
+	utils "github.com/noctisatrae/farseer/utils"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// The file listing every archive, one JSON entry per line, appended when an archive is closed.
+const manifestName = "manifest.ndjson"
+
+// Suffix of the archive being written: it's renamed when it's closed, so a file without it is complete.
+const partialSuffix = ".partial"
+
+// What the manifest says about an archive. The hashes are compared as hex strings, so a hash is only in the files
+// where MinHash <= hash <= MaxHash.
+type ManifestEntry struct {
+	File        string    `json:"file"`
+	Format      string    `json:"format"`
+	Compression string    `json:"compression"`
+	Messages    uint64    `json:"messages"`
+	Bytes       uint64    `json:"bytes"`
+	FirstHash   string    `json:"first_hash,omitempty"`
+	LastHash    string    `json:"last_hash,omitempty"`
+	MinHash     string    `json:"min_hash,omitempty"`
+	MaxHash     string    `json:"max_hash,omitempty"`
+	OpenedAt    time.Time `json:"opened_at"`
+	ClosedAt    time.Time `json:"closed_at"`
+	// Set when the archive was left behind by a crash: it may end with a truncated message & its hashes are unknown.
+	Recovered bool `json:"recovered,omitempty"`
+}
+
+// An archive being written.
+type archiveFile struct {
+	path string
+	file *os.File
+	// the compressor when there's one, then the buffer
+	encoder io.WriteCloser
+	writer  *bufio.Writer
+	entry   ManifestEntry
+}
+
+// The name of an archive: when it was opened, then its extension.
+func archiveName(openedAt time.Time, format string, compression string) string {
+	name := "farseer-" + openedAt.UTC().Format("20060102T150405.000000000Z") + "." + format
+	if compression == CompressionZstd {
+		name += ".zst"
+	}
+	return name
+}
+
+func openArchive(directory string, format string, compression string, openedAt time.Time) (*archiveFile, error) {
+	name := archiveName(openedAt, format, compression)
+	path := filepath.Join(directory, name)
+	file, err := os.OpenFile(path+partialSuffix, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &archiveFile{
+		path: path,
+		file: file,
+		entry: ManifestEntry{
+			File:        name,
+			Format:      format,
+			Compression: compression,
+			OpenedAt:    openedAt.UTC(),
+		},
+	}
+
+	var w io.Writer = file
+	if compression == CompressionZstd {
+		encoder, err := zstd.NewWriter(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		a.encoder = encoder
+		w = encoder
+	}
+	a.writer = bufio.NewWriter(w)
+
+	return a, nil
+}
+
+// Appends a message & keeps track of its hash. The size counted is the one before compression.
+func (a *archiveFile) Write(m *protos.Message) error {
+	var encoded []byte
+	var err error
+	if a.entry.Format == FormatNDJSON {
+		encoded, err = protojson.Marshal(m)
+		encoded = append(encoded, '\n')
+	} else {
+		var buf bytes.Buffer
+		_, err = protodelim.MarshalTo(&buf, m)
+		encoded = buf.Bytes()
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.writer.Write(encoded); err != nil {
+		return err
+	}
+
+	hash := utils.BytesToHex(m.Hash)
+	if a.entry.Messages == 0 {
+		a.entry.FirstHash, a.entry.MinHash, a.entry.MaxHash = hash, hash, hash
+	}
+	a.entry.LastHash = hash
+	a.entry.MinHash = min(a.entry.MinHash, hash)
+	a.entry.MaxHash = max(a.entry.MaxHash, hash)
+	a.entry.Messages++
+	a.entry.Bytes += uint64(len(encoded))
+
+	return nil
+}
+
+// Flushes & closes the archive, then renames it so it's seen as complete.
+func (a *archiveFile) Close(closedAt time.Time) (ManifestEntry, error) {
+	err := a.writer.Flush()
+	if a.encoder != nil {
+		err = firstError(err, a.encoder.Close())
+	}
+	err = firstError(err, a.file.Sync())
+	err = firstError(err, a.file.Close())
+	if err != nil {
+		return a.entry, err
+	}
+
+	a.entry.ClosedAt = closedAt.UTC()
+	return a.entry, os.Rename(a.path+partialSuffix, a.path)
+}
+
+func firstError(err error, other error) error {
+	if err != nil {
+		return err
+	}
+	return other
+}
+
+// Appends an entry to the manifest of the directory.
+func appendManifest(directory string, entry ManifestEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(directory, manifestName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Reads the manifest of the directory.
+func ReadManifest(directory string) ([]ManifestEntry, error) {
+	content, err := os.ReadFile(filepath.Join(directory, manifestName))
+	if os.IsNotExist(err) {
+		return []ManifestEntry{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	entries := []ManifestEntry{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	for decoder.More() {
+		var entry ManifestEntry
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Renames the archives left behind by a crash & adds them to the manifest, so they aren't mistaken for the one
+// being written.
+func recoverPartials(directory string) ([]ManifestEntry, error) {
+	partials, err := filepath.Glob(filepath.Join(directory, "*"+partialSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	recovered := []ManifestEntry{}
+	for _, partial := range partials {
+		info, err := os.Stat(partial)
+		if err != nil {
+			return recovered, err
+		}
+		path := strings.TrimSuffix(partial, partialSuffix)
+		if err := os.Rename(partial, path); err != nil {
+			return recovered, err
+		}
+
+		// the format & the compression are given by the extensions: farseer-<time>.<format>[.zst]
+		name := filepath.Base(path)
+		entry := ManifestEntry{
+			File:        name,
+			Compression: CompressionNone,
+			ClosedAt:    info.ModTime().UTC(),
+			Recovered:   true,
+		}
+		if strings.HasSuffix(name, ".zst") {
+			entry.Compression = CompressionZstd
+			name = strings.TrimSuffix(name, ".zst")
+		}
+		entry.Format = strings.TrimPrefix(filepath.Ext(name), ".")
+		if err := appendManifest(directory, entry); err != nil {
+			return recovered, err
+		}
+		recovered = append(recovered, entry)
+	}
+
+	return recovered, nil
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
-	"github.com/pelletier/go-toml/v2"
+	"fmt"
 	"os"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
 )
 
 type HubParams struct {
@@ -13,6 +16,70 @@ type HubParams struct {
 	Debug           bool
 	BufferSize      uint
 	ContactInterval uint
+	// The DNS name pointing to PublicHubIp announced in the contact info, optional. It can be announced alone when
+	// PublicHubIp is empty.
+	PublicDnsName string
+	// The interface the gRPC API listens on, localhost by default. The RPC address is only announced in the contact
+	// info when it isn't a loopback one.
+	RpcHost string
+	// Which Farcaster network the hub is part of: 1 = mainnet, 2 = testnet, 3 = devnet.
+	Network uint
+	// Where the messages seen on gossip are stored to be served by the RPCs.
+	StorePath string
+	// What to do when the store can't keep up, like the Backpressure of a plugin: "drop-newest" by default so a slow
+	// disk doesn't hold back the plugins.
+	StoreBackpressure string
+	// The port serving the Prometheus metrics on /metrics & the health checks on /healthz & /readyz, 2284 by default.
+	MetricsPort uint
+	// The hub is ready once it's connected to this many peers, 1 by default.
+	ReadyMinPeers uint
+	// The hub is ready while a message of the primary topic was received in this many seconds, 60 by default.
+	ReadyMessageWindow uint
+	// Where the AdminService listens, localhost:2285 by default so it can only be reached from the machine.
+	AdminAddress string
+	// The peers announced in the contact infos are dialed until the hub has this many outbound connections, 20 by
+	// default.
+	MaxOutboundPeers uint
+	// The contact infos of the hubs running an older version of the protocol are ignored, the one of farseer by
+	// default.
+	MinHubVersion string
+}
+
+// The keys of a [handlers.<name>] table that are read by the relay itself. They're never passed to the plugin.
+var relayKeys = map[string]bool{
+	"Enabled":      true,
+	"Backpressure": true,
+	"BufferSize":   true,
+	"Validation":   true,
+	"Command":      true,
+	"Filter":       true,
+	// deprecated, turned into a Filter
+	"FidsAllowed":         true,
+	"MessageTypesAllowed": true,
+}
+
+// The keys the plugins used to filter the messages themselves with, before the Filter of the relay. They're still
+// accepted & turned into a Filter on the same field.
+var legacyFilterKeys = []struct {
+	key   string
+	field string
+}{
+	{"MessageTypesAllowed", "type"},
+	{"FidsAllowed", "fid"},
+}
+
+// How the relay feeds messages to a plugin, configured in its [handlers.<name>] table.
+type DispatchParams struct {
+	// What to do when the plugin's buffer is full: "block", "drop-oldest" or "drop-newest".
+	Backpressure string
+	// Size of the plugin's buffer, defaults to hub.BufferSize.
+	BufferSize uint
+	// Does the plugin only get the messages with a valid hash & signature ("verified", default) or all of them ("raw")?
+	Validation string
+	// The command launching the plugin in its own process, empty for the plugins loaded from compiled_handlers/<name>.so.
+	Command []string
+	// Only the messages matching this expression are given to the plugin, see the filter package. Empty for all of them.
+	Filter string
 }
 
 type Config struct {
@@ -27,12 +94,18 @@ func Load(path string) (Config, error) {
 	if err != nil {
 		return Config{
 			Hub: HubParams{
-				GossipPort:      2282,
-				RpcPort:         2283,
-				BootstrapPeers:  []string{"/dns/nemes.farcaster.xyz/tcp/2282/p2p/12D3KooWMQrf6unpGJfLBmTGy3eKTo4cGcXktWRbgMnfbZLXqBbn"},
-				Debug:           true,
-				BufferSize:      128,
-				ContactInterval: 30,
+				GossipPort:        2282,
+				RpcPort:           2283,
+				BootstrapPeers:    []string{"/dns/nemes.farcaster.xyz/tcp/2282/p2p/12D3KooWMQrf6unpGJfLBmTGy3eKTo4cGcXktWRbgMnfbZLXqBbn"},
+				Debug:             true,
+				BufferSize:        128,
+				ContactInterval:   30,
+				Network:           1,
+				StorePath:         "farseer.db",
+				StoreBackpressure: "drop-newest",
+				MetricsPort:       2284,
+				AdminAddress:      "localhost:2285",
+				RpcHost:           "localhost",
 			},
 		}, err
 	}
@@ -41,15 +114,49 @@ func Load(path string) (Config, error) {
 	if err != nil {
 		return Config{
 			Hub: HubParams{
-				GossipPort:      2282,
-				BootstrapPeers:  []string{"/dns/nemes.farcaster.xyz/tcp/2282/p2p/12D3KooWMQrf6unpGJfLBmTGy3eKTo4cGcXktWRbgMnfbZLXqBbn"},
-				Debug:           true,
-				BufferSize:      128,
-				ContactInterval: 30,
+				GossipPort:        2282,
+				BootstrapPeers:    []string{"/dns/nemes.farcaster.xyz/tcp/2282/p2p/12D3KooWMQrf6unpGJfLBmTGy3eKTo4cGcXktWRbgMnfbZLXqBbn"},
+				Debug:             true,
+				BufferSize:        128,
+				ContactInterval:   30,
+				Network:           1,
+				StorePath:         "farseer.db",
+				StoreBackpressure: "drop-newest",
+				MetricsPort:       2284,
+				AdminAddress:      "localhost:2285",
+				RpcHost:           "localhost",
 			},
 		}, err
 	}
 
+	if config.Hub.Network == 0 {
+		config.Hub.Network = 1
+	}
+	if config.Hub.StorePath == "" {
+		config.Hub.StorePath = "farseer.db"
+	}
+	if config.Hub.StoreBackpressure == "" {
+		config.Hub.StoreBackpressure = "drop-newest"
+	}
+	if config.Hub.MetricsPort == 0 {
+		config.Hub.MetricsPort = 2284
+	}
+	if config.Hub.ReadyMinPeers == 0 {
+		config.Hub.ReadyMinPeers = 1
+	}
+	if config.Hub.ReadyMessageWindow == 0 {
+		config.Hub.ReadyMessageWindow = 60
+	}
+	if config.Hub.AdminAddress == "" {
+		config.Hub.AdminAddress = "localhost:2285"
+	}
+	if config.Hub.MaxOutboundPeers == 0 {
+		config.Hub.MaxOutboundPeers = 20
+	}
+	if config.Hub.RpcHost == "" {
+		config.Hub.RpcHost = "localhost"
+	}
+
 	return config, nil
 }
 
@@ -74,9 +181,78 @@ func (conf Config) GetParams(handler string) map[string]interface{} {
 
 	params := map[string]interface{}{}
 	for key, value := range handlerConfig.(map[string]interface{}) {
-		if key != "Enabled" {
+		if !relayKeys[key] {
 			params[key] = value
 		}
 	}
 	return params
 }
+
+// The deprecated keys set in the table of the handler, to warn about them.
+func (conf Config) DeprecatedKeys(handler string) []string {
+	handlerConfig, ok := conf.Handlers[handler].(map[string]interface{})
+	if !ok {
+		return []string{}
+	}
+
+	keys := []string{}
+	for _, legacy := range legacyFilterKeys {
+		if _, ok := handlerConfig[legacy.key]; ok {
+			keys = append(keys, legacy.key)
+		}
+	}
+	return keys
+}
+
+func (conf Config) GetDispatchParams(handler string) DispatchParams {
+	dispatchParams := DispatchParams{
+		Backpressure: "block",
+		BufferSize:   conf.Hub.BufferSize,
+		Validation:   "verified",
+	}
+
+	handlerConfig, ok := conf.Handlers[handler].(map[string]interface{})
+	if !ok {
+		return dispatchParams
+	}
+
+	if backpressure, ok := handlerConfig["Backpressure"].(string); ok {
+		dispatchParams.Backpressure = backpressure
+	}
+	if bufferSize, ok := handlerConfig["BufferSize"].(int64); ok && bufferSize > 0 {
+		dispatchParams.BufferSize = uint(bufferSize)
+	}
+	if validation, ok := handlerConfig["Validation"].(string); ok {
+		dispatchParams.Validation = validation
+	}
+	if filter, ok := handlerConfig["Filter"].(string); ok {
+		dispatchParams.Filter = filter
+	}
+	comparisons := []string{}
+	for _, legacy := range legacyFilterKeys {
+		values, ok := handlerConfig[legacy.key].([]interface{})
+		if !ok || len(values) == 0 {
+			continue
+		}
+		formatted := make([]string, len(values))
+		for i, value := range values {
+			formatted[i] = fmt.Sprint(value)
+		}
+		comparisons = append(comparisons, fmt.Sprintf("%s in (%s)", legacy.field, strings.Join(formatted, ", ")))
+	}
+	if len(comparisons) > 0 && dispatchParams.Filter != "" {
+		dispatchParams.Filter = fmt.Sprintf("(%s) and %s", dispatchParams.Filter, strings.Join(comparisons, " and "))
+	} else if len(comparisons) > 0 {
+		dispatchParams.Filter = strings.Join(comparisons, " and ")
+	}
+	switch command := handlerConfig["Command"].(type) {
+	case string:
+		dispatchParams.Command = strings.Fields(command)
+	case []interface{}:
+		for _, arg := range command {
+			dispatchParams.Command = append(dispatchParams.Command, fmt.Sprint(arg))
+		}
+	}
+
+	return dispatchParams
+}
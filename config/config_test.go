@@ -1,6 +1,8 @@
 package config_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	config "github.com/noctisatrae/farseer/config"
@@ -19,13 +21,22 @@ func TestLoadConfig(t *testing.T) {
 
 	// always-the-same option test
 	assert.Equal(t, config.HubParams{
-		PublicHubIp:     "92.158.95.48",
-		GossipPort:      2282,
-		RpcPort:         2283,
-		BootstrapPeers:  []string{},
-		Debug:           false,
-		BufferSize:      128,
-		ContactInterval: 3000,
+		PublicHubIp:        "92.158.95.48",
+		GossipPort:         2282,
+		RpcPort:            2283,
+		BootstrapPeers:     []string{},
+		Debug:              false,
+		BufferSize:         128,
+		ContactInterval:    3000,
+		Network:            1,
+		StorePath:          "farseer.db",
+		StoreBackpressure:  "drop-newest",
+		MetricsPort:        2284,
+		ReadyMinPeers:      1,
+		ReadyMessageWindow: 60,
+		AdminAddress:       "localhost:2285",
+		MaxOutboundPeers:   20,
+		RpcHost:            "localhost",
 	}, conf.Hub)
 
 	// dynamic conf
@@ -37,5 +48,54 @@ func TestParamsFromConf(t *testing.T) {
 	conf, err := config.Load("../config.toml")
 	assert.NoError(t, err)
 
-	assert.Equal(t, map[string]interface{}{"DbAddress": "postgres://postgres:example@db:5432/postgres", "FidsAllowed": []interface{}{int64(10626)}}, conf.GetParams("postgresql"))
+	assert.Equal(t, map[string]interface{}{"DbAddress": "postgres://postgres:example@db:5432/postgres"}, conf.GetParams("postgresql"))
+	assert.Equal(t, "fid == 10626", conf.GetDispatchParams("postgresql").Filter)
+}
+
+// are the dispatch options read from the handler's table with defaults from the hub?
+func TestDispatchParamsFromConf(t *testing.T) {
+	conf := config.Config{
+		Hub: config.HubParams{BufferSize: 128},
+		Handlers: map[string]interface{}{
+			"fast": map[string]interface{}{"Enabled": true, "Backpressure": "drop-oldest", "BufferSize": int64(1024), "Validation": "raw", "Filter": "fid == 10626", "Foo": "bar"},
+			"slow": map[string]interface{}{"Enabled": true},
+			"proc": map[string]interface{}{"Enabled": true, "Command": []interface{}{"compiled_handlers/proc", "--verbose"}},
+			"sh":   map[string]interface{}{"Enabled": true, "Command": "python3 plugin.py"},
+		},
+	}
+
+	assert.Equal(t, config.DispatchParams{Backpressure: "drop-oldest", BufferSize: 1024, Validation: "raw", Filter: "fid == 10626"}, conf.GetDispatchParams("fast"))
+	assert.Equal(t, config.DispatchParams{Backpressure: "block", BufferSize: 128, Validation: "verified"}, conf.GetDispatchParams("slow"))
+	assert.Equal(t, []string{"compiled_handlers/proc", "--verbose"}, conf.GetDispatchParams("proc").Command)
+	assert.Equal(t, []string{"python3", "plugin.py"}, conf.GetDispatchParams("sh").Command)
+	assert.Equal(t, map[string]interface{}{"Foo": "bar"}, conf.GetParams("fast"))
+	assert.Equal(t, map[string]interface{}{}, conf.GetParams("proc"))
+}
+
+// are the options left out of config.toml given their defaults?
+func TestLoadDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	assert.NoError(t, os.WriteFile(path, []byte("[hub]\nGossipPort = 2282\n"), 0o644))
+
+	conf, err := config.Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2284), conf.Hub.MetricsPort, "the health checks are served by default")
+	assert.Equal(t, "drop-newest", conf.Hub.StoreBackpressure)
+	assert.Equal(t, "localhost", conf.Hub.RpcHost)
+}
+
+// are the filtering keys of the plugins from before Filter turned into one?
+func TestLegacyFilterKeys(t *testing.T) {
+	conf := config.Config{
+		Handlers: map[string]interface{}{
+			"legacy": map[string]interface{}{"Enabled": true, "MessageTypesAllowed": []interface{}{int64(1), int64(2)}, "FidsAllowed": []interface{}{int64(10626)}, "DbAddress": "db"},
+			"both":   map[string]interface{}{"Enabled": true, "Filter": "channel == 'farcaster' or fid == 3", "FidsAllowed": []interface{}{int64(10626)}},
+		},
+	}
+
+	assert.Equal(t, "type in (1, 2) and fid in (10626)", conf.GetDispatchParams("legacy").Filter)
+	assert.Equal(t, map[string]interface{}{"DbAddress": "db"}, conf.GetParams("legacy"), "the plugin doesn't refuse the keys it doesn't know anymore")
+	assert.Equal(t, "(channel == 'farcaster' or fid == 3) and fid in (10626)", conf.GetDispatchParams("both").Filter)
+	assert.Equal(t, []string{"MessageTypesAllowed", "FidsAllowed"}, conf.DeprecatedKeys("legacy"))
+	assert.Empty(t, conf.DeprecatedKeys("unknown"))
 }
@@ -0,0 +1,223 @@
+// Package filter selects the messages given to a plugin with a small expression language, set with Filter in the
+// [handlers.<name>] table of config.toml:
+//
+//	Filter = "type in (cast_add, cast_remove) and (fid in (10626, 3) or mention == 10626)"
+//
+// A comparison is a field, an operator & a value:
+//   - fid: the user who sent the message
+//   - type: its type, named like in the MessageType enum (cast_add, reaction_add...) or by its number
+//   - mention: the users mentioned in a cast
+//   - parent_url: the URL a cast replies to
+//   - channel: the channel of a cast, the name at the end of its https://warpcast.com/~/channel/<name> parent URL
+//   - text: the text of a cast
+//   - embed_domain: the domains of the URLs embedded in a cast, a subdomain matches its parent domain
+//
+// The operators are == & != with a value, in with a list of values between parentheses, and matches with a regular
+// expression (RE2 syntax) for the fields holding text. A field holding several values (mention, embed_domain)
+// matches when one of them does; a field the message doesn't have matches nothing, so only != is true. Comparisons
+// are combined with and, or & not, and grouped with parentheses.
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	protos "github.com/noctisatrae/farseer/protos"
+)
+
+// The parent URL of the casts in a channel, followed by its name.
+const ChannelPrefix = "https://warpcast.com/~/channel/"
+
+// A parsed expression. A nil Filter matches every message.
+type Filter struct {
+	expr string
+	root node
+}
+
+// Parses an expression, an empty one gives a nil Filter.
+func Parse(expr string) (*Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+
+	p := &parser{lexer: lexer{input: expr}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.token.kind != tokenEOF {
+		return nil, p.errorf("unexpected %s", p.token)
+	}
+
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// Does the message match the expression? A message without data never does.
+func (f *Filter) Match(m *protos.Message) bool {
+	if f == nil {
+		return true
+	}
+	if m.GetData() == nil {
+		return false
+	}
+	return f.root.match(m.Data)
+}
+
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expr
+}
+
+type node interface {
+	match(data *protos.MessageData) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) match(data *protos.MessageData) bool {
+	return n.left.match(data) && n.right.match(data)
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) match(data *protos.MessageData) bool {
+	return n.left.match(data) || n.right.match(data)
+}
+
+type notNode struct{ node node }
+
+func (n notNode) match(data *protos.MessageData) bool {
+	return !n.node.match(data)
+}
+
+// The fields holding numbers are compared with numbers, the others with strings.
+type field struct {
+	numeric bool
+	// the values of the field in the message, none if it doesn't have it
+	numbers func(data *protos.MessageData) []uint64
+	strings func(data *protos.MessageData) []string
+	// are two strings the same? Exact by default.
+	equal func(value string, expected string) bool
+}
+
+var fields = map[string]field{
+	"fid": {numeric: true, numbers: func(data *protos.MessageData) []uint64 {
+		return []uint64{data.Fid}
+	}},
+	"type": {numeric: true, numbers: func(data *protos.MessageData) []uint64 {
+		return []uint64{uint64(data.Type)}
+	}},
+	"mention": {numeric: true, numbers: func(data *protos.MessageData) []uint64 {
+		return data.GetCastAddBody().GetMentions()
+	}},
+	"parent_url": {strings: func(data *protos.MessageData) []string {
+		if parentUrl := data.GetCastAddBody().GetParentUrl(); parentUrl != "" {
+			return []string{parentUrl}
+		}
+		return nil
+	}},
+	"channel": {strings: func(data *protos.MessageData) []string {
+		if channel, ok := strings.CutPrefix(data.GetCastAddBody().GetParentUrl(), ChannelPrefix); ok && channel != "" {
+			return []string{channel}
+		}
+		return nil
+	}},
+	"text": {strings: func(data *protos.MessageData) []string {
+		if body := data.GetCastAddBody(); body != nil {
+			return []string{body.Text}
+		}
+		return nil
+	}},
+	"embed_domain": {strings: embedDomains, equal: func(domain string, expected string) bool {
+		expected = strings.ToLower(expected)
+		return domain == expected || strings.HasSuffix(domain, "."+expected)
+	}},
+}
+
+// The hosts of the URLs embedded in a cast, lowercased.
+func embedDomains(data *protos.MessageData) []string {
+	body := data.GetCastAddBody()
+	if body == nil {
+		return nil
+	}
+
+	urls := slices.Clone(body.EmbedsDeprecated)
+	for _, embed := range body.Embeds {
+		if embed.GetUrl() != "" {
+			urls = append(urls, embed.GetUrl())
+		}
+	}
+
+	domains := []string{}
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		domains = append(domains, strings.ToLower(u.Hostname()))
+	}
+	return domains
+}
+
+// A field compared with one or more values: ==, != & in.
+type comparison struct {
+	field   field
+	negate  bool
+	numbers []uint64
+	strings []string
+}
+
+func (c comparison) match(data *protos.MessageData) bool {
+	found := false
+	if c.field.numeric {
+		for _, value := range c.field.numbers(data) {
+			if slices.Contains(c.numbers, value) {
+				found = true
+				break
+			}
+		}
+	} else {
+		equal := c.field.equal
+		if equal == nil {
+			equal = func(value string, expected string) bool { return value == expected }
+		}
+		for _, value := range c.field.strings(data) {
+			if slices.ContainsFunc(c.strings, func(expected string) bool { return equal(value, expected) }) {
+				found = true
+				break
+			}
+		}
+	}
+	return found != c.negate
+}
+
+// A field holding text compared with a regular expression.
+type regexpMatch struct {
+	field field
+	re    *regexp.Regexp
+}
+
+func (r regexpMatch) match(data *protos.MessageData) bool {
+	return slices.ContainsFunc(r.field.strings(data), r.re.MatchString)
+}
+
+// The number of a type of message, from its name (cast_add or MESSAGE_TYPE_CAST_ADD).
+func messageType(name string) (uint64, error) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "MESSAGE_TYPE_") {
+		name = "MESSAGE_TYPE_" + name
+	}
+	value, ok := protos.MessageType_value[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown type of message %q", name)
+	}
+	return uint64(value), nil
+}
@@ -0,0 +1,110 @@
+package filter_test
+
+import (
+	"testing"
+
+	"github.com/noctisatrae/farseer/filter"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func cast(fid uint64, body *protos.CastAddBody) *protos.Message {
+	return &protos.Message{Data: &protos.MessageData{
+		Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD,
+		Fid:  fid,
+		Body: &protos.MessageData_CastAddBody{CastAddBody: body},
+	}}
+}
+
+// Do the expressions match the messages they should?
+func TestMatch(t *testing.T) {
+	gm := cast(10626, &protos.CastAddBody{
+		Text:     "gm farcaster",
+		Mentions: []uint64{3, 2},
+		Parent:   &protos.CastAddBody_ParentUrl{ParentUrl: filter.ChannelPrefix + "farcaster"},
+		Embeds: []*protos.Embed{
+			{Embed: &protos.Embed_Url{Url: "https://www.YouTube.com/watch?v=dQw4w9WgXcQ"}},
+			{Embed: &protos.Embed_CastId{CastId: &protos.CastId{Fid: 3}}},
+		},
+	})
+	like := &protos.Message{Data: &protos.MessageData{
+		Type: protos.MessageType_MESSAGE_TYPE_REACTION_ADD,
+		Fid:  3,
+		Body: &protos.MessageData_ReactionBody{ReactionBody: &protos.ReactionBody{Type: protos.ReactionType_REACTION_TYPE_LIKE}},
+	}}
+
+	tests := []struct {
+		expr string
+		gm   bool
+		like bool
+	}{
+		{"fid == 10626", true, false},
+		{"fid != 10626", false, true},
+		{"fid in (1, 3)", false, true},
+		{"type == cast_add", true, false},
+		{"type in (MESSAGE_TYPE_REACTION_ADD, 2)", false, true},
+		{"type == 1", true, false},
+		{"mention == 2", true, false},
+		{"mention in (4, 5)", false, false},
+		{"mention != 2", false, true},
+		{`parent_url == "https://warpcast.com/~/channel/farcaster"`, true, false},
+		{"channel == 'farcaster'", true, false},
+		{"channel in ('memes', 'farcaster')", true, false},
+		{"text matches '(?i)^GM\\b'", true, false},
+		{`text matches "gn"`, false, false},
+		{"embed_domain == 'youtube.com'", true, false},
+		{"embed_domain == 'tube.com'", false, false},
+		{"fid == 3 or mention == 3", true, true},
+		{"type == cast_add and not (fid == 10626)", false, false},
+		{"not fid == 10626 and type == reaction_add", false, true},
+		{"fid == 1 or fid == 3 and type == cast_add", false, false},
+		{"(fid == 1 or fid == 3) and type == reaction_add", false, true},
+		{"FID == 10626 AND TYPE == CAST_ADD", true, false},
+	}
+	for _, test := range tests {
+		f, err := filter.Parse(test.expr)
+		if !assert.NoError(t, err, test.expr) {
+			continue
+		}
+		assert.Equal(t, test.gm, f.Match(gm), test.expr)
+		assert.Equal(t, test.like, f.Match(like), test.expr)
+	}
+
+	// a message without data can't be filtered
+	f, err := filter.Parse("fid != 1")
+	assert.NoError(t, err)
+	assert.False(t, f.Match(&protos.Message{}))
+}
+
+// Does an empty expression let everything through?
+func TestEmpty(t *testing.T) {
+	f, err := filter.Parse("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, f)
+	assert.True(t, f.Match(&protos.Message{}))
+	assert.Equal(t, "", f.String())
+}
+
+// Are the invalid expressions refused, with where the problem is?
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"fids == 1":                   "unknown field",
+		"fid == 'a'":                  "expected a number",
+		"channel == farcaster":        "expected a string",
+		"type == cats_add":            "unknown type of message",
+		"fid matches '1'":             "can't be matched",
+		"text matches '('":            "invalid regular expression",
+		"fid == 1 and":                "expected a field",
+		"fid == 1 fid == 2":           "unexpected",
+		"(fid == 1":                   "expected )",
+		"fid in 1":                    "expected ( after in",
+		"fid > 1":                     "unexpected",
+		"text == 'unterminated":       "unterminated string",
+		"fid == 99999999999999999999": "invalid number",
+	}
+	for expr, message := range tests {
+		_, err := filter.Parse(expr)
+		assert.ErrorContains(t, err, message, expr)
+	}
+}
@@ -0,0 +1,307 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenEq
+	tokenNeq
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of the filter"
+	}
+	return strconv.Quote(t.text)
+}
+
+// Is the token this keyword? The keywords aren't case sensitive.
+func (t token) is(keyword string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) && unicode.IsSpace(rune(l.input[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.input) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	c := l.input[l.pos]
+	switch {
+	case c == '(':
+		l.pos++
+		return token{kind: tokenLParen, text: "(", pos: start}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokenRParen, text: ")", pos: start}, nil
+	case c == ',':
+		l.pos++
+		return token{kind: tokenComma, text: ",", pos: start}, nil
+	case strings.HasPrefix(l.input[l.pos:], "=="):
+		l.pos += 2
+		return token{kind: tokenEq, text: "==", pos: start}, nil
+	case strings.HasPrefix(l.input[l.pos:], "!="):
+		l.pos += 2
+		return token{kind: tokenNeq, text: "!=", pos: start}, nil
+	case c == '"' || c == '\'':
+		return l.string(c)
+	case c >= '0' && c <= '9':
+		for l.pos < len(l.input) && l.input[l.pos] >= '0' && l.input[l.pos] <= '9' {
+			l.pos++
+		}
+		text := l.input[start:l.pos]
+		return token{kind: tokenNumber, text: text, value: text, pos: start}, nil
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for l.pos < len(l.input) && (l.input[l.pos] == '_' || unicode.IsLetter(rune(l.input[l.pos])) || unicode.IsDigit(rune(l.input[l.pos]))) {
+			l.pos++
+		}
+		text := l.input[start:l.pos]
+		return token{kind: tokenIdent, text: text, value: text, pos: start}, nil
+	}
+
+	return token{}, fmt.Errorf("invalid filter: unexpected %q at %d", c, start)
+}
+
+// A string between quotes: "..." is unquoted like in Go, '...' is taken as is, handy for regular expressions.
+func (l *lexer) string(quote byte) (token, error) {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.input) {
+		switch l.input[l.pos] {
+		case '\\':
+			if quote == '"' {
+				l.pos++
+			}
+		case quote:
+			l.pos++
+			text := l.input[start:l.pos]
+			if quote == '\'' {
+				return token{kind: tokenString, text: text, value: text[1 : len(text)-1], pos: start}, nil
+			}
+			value, err := strconv.Unquote(text)
+			if err != nil {
+				return token{}, fmt.Errorf("invalid filter: invalid string %s at %d", text, start)
+			}
+			return token{kind: tokenString, text: text, value: value, pos: start}, nil
+		}
+		l.pos++
+	}
+	return token{}, fmt.Errorf("invalid filter: unterminated string at %d", start)
+}
+
+// A recursive descent parser, the precedence going from or (lowest) to not (highest).
+type parser struct {
+	lexer lexer
+	token token
+}
+
+func (p *parser) advance() error {
+	t, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = t
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid filter: %s at %d", fmt.Sprintf(format, args...), p.token.pos)
+}
+
+func (p *parser) expect(kind tokenKind, what string) error {
+	if p.token.kind != kind {
+		return p.errorf("expected %s, got %s", what, p.token)
+	}
+	return p.advance()
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.token.is("or") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.token.is("and") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch {
+	case p.token.is("not"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case p.token.kind == tokenLParen:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(tokenRParen, ")")
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *parser) parseComparison() (node, error) {
+	if p.token.kind != tokenIdent {
+		return nil, p.errorf("expected a field, got %s", p.token)
+	}
+	name := strings.ToLower(p.token.text)
+	f, ok := fields[name]
+	if !ok {
+		return nil, p.errorf("unknown field %s", p.token)
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	op := p.token
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case op.kind == tokenEq || op.kind == tokenNeq:
+		value, err := p.parseValue(name, f)
+		if err != nil {
+			return nil, err
+		}
+		c := comparison{field: f, negate: op.kind == tokenNeq}
+		return c.with(value), nil
+	case op.is("in"):
+		if err := p.expect(tokenLParen, "( after in"); err != nil {
+			return nil, err
+		}
+		c := comparison{field: f}
+		for {
+			value, err := p.parseValue(name, f)
+			if err != nil {
+				return nil, err
+			}
+			c = c.with(value)
+			if p.token.kind != tokenComma {
+				break
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		return c, p.expect(tokenRParen, ")")
+	case op.is("matches"):
+		if f.numeric {
+			return nil, fmt.Errorf("invalid filter: %s holds numbers, it can't be matched with a regular expression at %d", name, op.pos)
+		}
+		if p.token.kind != tokenString {
+			return nil, p.errorf("expected a regular expression between quotes, got %s", p.token)
+		}
+		re, err := regexp.Compile(p.token.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: invalid regular expression at %d: %w", p.token.pos, err)
+		}
+		return regexpMatch{field: f, re: re}, p.advance()
+	}
+
+	return nil, fmt.Errorf("invalid filter: expected ==, !=, in or matches after %s, got %s at %d", name, op, op.pos)
+}
+
+// A value of a field: a number for the numeric fields, or a name for the types, a string for the others.
+type value struct {
+	number uint64
+	str    string
+}
+
+func (c comparison) with(v value) comparison {
+	if c.field.numeric {
+		c.numbers = append(c.numbers, v.number)
+	} else {
+		c.strings = append(c.strings, v.str)
+	}
+	return c
+}
+
+func (p *parser) parseValue(name string, f field) (value, error) {
+	t := p.token
+	switch {
+	case f.numeric && t.kind == tokenNumber:
+		number, err := strconv.ParseUint(t.value, 10, 64)
+		if err != nil {
+			return value{}, p.errorf("invalid number %s", t)
+		}
+		return value{number: number}, p.advance()
+	case name == "type" && t.kind == tokenIdent:
+		number, err := messageType(t.value)
+		if err != nil {
+			return value{}, p.errorf("%s", err)
+		}
+		return value{number: number}, p.advance()
+	case !f.numeric && t.kind == tokenString:
+		return value{str: t.value}, p.advance()
+	case f.numeric:
+		return value{}, p.errorf("expected a number for %s, got %s", name, t)
+	default:
+		return value{}, p.errorf("expected a string between quotes for %s, got %s", name, t)
+	}
+}
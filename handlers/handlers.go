@@ -24,6 +24,8 @@ type Handler struct {
 	// rough scheme of the look of your params map. Because it's an interface and not a strongly typed struct, it can lead to
 	// panicking if things aren't well queried.
 	// You also might want some checks to see if the info you need from the config are there!
+	// Before each call to a HandlerBehaviour, params["bundleHash"] & params["peerId"] are set to the MessageMeta of the
+	// message being handled, so don't use those keys for your own values.
 	InitHandler               InitBehaviour
 	CastAddHandler            HandlerBehaviour
 	CastRemoveHandler         HandlerBehaviour
@@ -34,6 +36,44 @@ type Handler struct {
 	LinkRemoveHandler         HandlerBehaviour
 	VerificationAddHandler    HandlerBehaviour
 	VerificationRemoveHandler HandlerBehaviour
+	// Profile updates: pfp, display name, bio, url & preferred username.
+	UserDataAddHandler HandlerBehaviour
+	// Proofs of ownership of fnames & ENS names, see the UserNameProof message of username_proof.proto.
+	UsernameProofHandler HandlerBehaviour
+	// Snapshot of the links of a user, sent when they have too many for the hub to keep every LinkAdd.
+	LinkCompactStateHandler HandlerBehaviour
+	// Called for every message, whatever its type, before the handler of its type.
+	AnyMessageHandler HandlerBehaviour
+}
+
+// Where a Farcaster message comes from. It's given to the handlers through the params map, under the "bundleHash" &
+// "peerId" keys which are updated before every call.
+type MessageMeta struct {
+	// Hash of the MessageBundle that contained the message, empty if the message was gossiped on its own.
+	BundleHash []byte
+	// Encoded libp2p ID of the peer that originated the gossip message.
+	PeerId []byte
+}
+
+// Normalises the content of a gossip message: whether it carries a single message or a bundle, you get the list
+// of messages it contains & where they come from.
+func ExtractMessages(msgB *protos.GossipMessage) ([]*protos.Message, MessageMeta) {
+	meta := MessageMeta{
+		PeerId: msgB.GetPeerId(),
+	}
+
+	switch content := msgB.GetContent().(type) {
+	case *protos.GossipMessage_Message:
+		if content.Message == nil {
+			return []*protos.Message{}, meta
+		}
+		return []*protos.Message{content.Message}, meta
+	case *protos.GossipMessage_MessageBundle:
+		meta.BundleHash = content.MessageBundle.GetHash()
+		return content.MessageBundle.GetMessages(), meta
+	default:
+		return []*protos.Message{}, meta
+	}
 }
 
 func (handler Handler) HandleMessages(messages chan *protos.GossipMessage, ll log.Logger, params map[string]interface{}) {
@@ -46,95 +86,145 @@ func (handler Handler) HandleMessages(messages chan *protos.GossipMessage, ll lo
 		}
 	}
 	for msgB := range messages { // i hope that the chan only gives one message at a time so it's just O(n) and not O(n²)
-		for _, m := range msgB.GetMessageBundle().GetMessages() {
-			ll.Debug("Received msg?")
-			data := m.Data
-			hash := m.Hash
-			switch data.Type {
-			case protos.MessageType_MESSAGE_TYPE_CAST_ADD:
-				if handler.CastAddHandler == nil {
-					ll.Info("New cast published! |", "Body", data)
-				} else {
-					err := handler.CastAddHandler(data, hash, params)
-					if err != nil {
-						ll.Error("CastAdd handler encountered an error! |", "Error", err)
-					}
-				}
-			case protos.MessageType_MESSAGE_TYPE_CAST_REMOVE:
-				if handler.CastRemoveHandler == nil {
-					ll.Info("Cast was just removed! |", "Body", data)
-				} else {
-					err := handler.CastRemoveHandler(data, hash, params)
-					if err != nil {
-						ll.Error("CastRemove handler encountered an error! |", "Error", err)
-					}
-				}
-			case protos.MessageType_MESSAGE_TYPE_FRAME_ACTION:
-				if handler.FrameActionHandler == nil {
-					ll.Info("New frame interaction! |", "Action", data)
-				} else {
-					err := handler.FrameActionHandler(data, hash, params)
-					if err != nil {
-						ll.Error("FrameAction handler encountered an error! |", "Error", err)
-					}
-				}
-			case protos.MessageType_MESSAGE_TYPE_REACTION_ADD:
-				if handler.ReactionAddHandler == nil {
-					ll.Info("New reaction added! |", "Reaction", data)
-				} else {
-					err := handler.ReactionAddHandler(data, hash, params)
-					if err != nil {
-						ll.Error("ReactionAdd handler encountered an error! |", "Error", err)
-					}
-				}
-			case protos.MessageType_MESSAGE_TYPE_REACTION_REMOVE:
-				if handler.ReactionRemoveHandler == nil {
-					ll.Info("A reaction was removed! |", "Reaction", data)
-				} else {
-					err := handler.ReactionRemoveHandler(data, hash, params)
-					if err != nil {
-						ll.Error("ReactionRemove handler encountered an error! |", "Error", err)
-					}
-				}
-			case protos.MessageType_MESSAGE_TYPE_LINK_ADD:
-				if handler.LinkAddHandler == nil {
-					ll.Info("A link was added! |", "Link", data)
-				} else {
-					err := handler.LinkAddHandler(data, hash, params)
-					if err != nil {
-						ll.Error("LinkAdd handler encountered an error! |", "Error", err)
-					}
-				}
-			case protos.MessageType_MESSAGE_TYPE_LINK_REMOVE:
-				if handler.LinkRemoveHandler == nil {
-					ll.Info("A link was removed! |", "Link", data)
-				} else {
-					err := handler.LinkAddHandler(data, hash, params)
-					if err != nil {
-						ll.Error("LinkRemove handler encountered an error! |", "Error", err)
-					}
-				}
-			case protos.MessageType_MESSAGE_TYPE_VERIFICATION_ADD_ETH_ADDRESS:
-				if handler.VerificationAddHandler == nil {
-					ll.Info("A ETH address was just verified! |", "VerificationBody", data)
-				} else {
-					err := handler.VerificationAddHandler(data, hash, params)
-					if err != nil {
-						ll.Error("VerificationAdd handler encountered an error! |", "Error", err)
-					}
-				}
-			case protos.MessageType_MESSAGE_TYPE_VERIFICATION_REMOVE:
-				if handler.VerificationRemoveHandler == nil {
-					ll.Info("A ETH address was just removed! |", "VerificationBody", data)
-				} else {
-					err := handler.VerificationAddHandler(data, hash, params)
-					if err != nil {
-						ll.Error("VerificationRemove handler encountered an error! |", "Error", err)
-					}
-				}
-			default:
-				ll.Warn("Unhandled message type! |", "Type", data.Type)
+		msgs, meta := ExtractMessages(msgB)
+		for _, m := range msgs {
+			handler.HandleMessage(m, meta, ll, params)
+		}
+	}
+}
+
+// Calls the handler matching the type of a single message.
+func (handler Handler) HandleMessage(m *protos.Message, meta MessageMeta, ll log.Logger, params map[string]interface{}) {
+	ll.Debug("Received msg?")
+	data := m.Data
+	hash := m.Hash
+	if data == nil {
+		ll.Warn("Received a message without data! |", "Hash", hash)
+		return
+	}
+
+	if params != nil {
+		params["bundleHash"] = meta.BundleHash
+		params["peerId"] = meta.PeerId
+	}
+
+	if handler.AnyMessageHandler != nil {
+		err := handler.AnyMessageHandler(data, hash, params)
+		if err != nil {
+			ll.Error("AnyMessage handler encountered an error! |", "Error", err)
+		}
+	}
+
+	switch data.Type {
+	case protos.MessageType_MESSAGE_TYPE_CAST_ADD:
+		if handler.CastAddHandler == nil {
+			ll.Info("New cast published! |", "Body", data)
+		} else {
+			err := handler.CastAddHandler(data, hash, params)
+			if err != nil {
+				ll.Error("CastAdd handler encountered an error! |", "Error", err)
+			}
+		}
+	case protos.MessageType_MESSAGE_TYPE_CAST_REMOVE:
+		if handler.CastRemoveHandler == nil {
+			ll.Info("Cast was just removed! |", "Body", data)
+		} else {
+			err := handler.CastRemoveHandler(data, hash, params)
+			if err != nil {
+				ll.Error("CastRemove handler encountered an error! |", "Error", err)
+			}
+		}
+	case protos.MessageType_MESSAGE_TYPE_FRAME_ACTION:
+		if handler.FrameActionHandler == nil {
+			ll.Info("New frame interaction! |", "Action", data)
+		} else {
+			err := handler.FrameActionHandler(data, hash, params)
+			if err != nil {
+				ll.Error("FrameAction handler encountered an error! |", "Error", err)
+			}
+		}
+	case protos.MessageType_MESSAGE_TYPE_REACTION_ADD:
+		if handler.ReactionAddHandler == nil {
+			ll.Info("New reaction added! |", "Reaction", data)
+		} else {
+			err := handler.ReactionAddHandler(data, hash, params)
+			if err != nil {
+				ll.Error("ReactionAdd handler encountered an error! |", "Error", err)
+			}
+		}
+	case protos.MessageType_MESSAGE_TYPE_REACTION_REMOVE:
+		if handler.ReactionRemoveHandler == nil {
+			ll.Info("A reaction was removed! |", "Reaction", data)
+		} else {
+			err := handler.ReactionRemoveHandler(data, hash, params)
+			if err != nil {
+				ll.Error("ReactionRemove handler encountered an error! |", "Error", err)
+			}
+		}
+	case protos.MessageType_MESSAGE_TYPE_LINK_ADD:
+		if handler.LinkAddHandler == nil {
+			ll.Info("A link was added! |", "Link", data)
+		} else {
+			err := handler.LinkAddHandler(data, hash, params)
+			if err != nil {
+				ll.Error("LinkAdd handler encountered an error! |", "Error", err)
+			}
+		}
+	case protos.MessageType_MESSAGE_TYPE_LINK_REMOVE:
+		if handler.LinkRemoveHandler == nil {
+			ll.Info("A link was removed! |", "Link", data)
+		} else {
+			err := handler.LinkRemoveHandler(data, hash, params)
+			if err != nil {
+				ll.Error("LinkRemove handler encountered an error! |", "Error", err)
+			}
+		}
+	case protos.MessageType_MESSAGE_TYPE_VERIFICATION_ADD_ETH_ADDRESS:
+		if handler.VerificationAddHandler == nil {
+			ll.Info("A ETH address was just verified! |", "VerificationBody", data)
+		} else {
+			err := handler.VerificationAddHandler(data, hash, params)
+			if err != nil {
+				ll.Error("VerificationAdd handler encountered an error! |", "Error", err)
+			}
+		}
+	case protos.MessageType_MESSAGE_TYPE_VERIFICATION_REMOVE:
+		if handler.VerificationRemoveHandler == nil {
+			ll.Info("A ETH address was just removed! |", "VerificationBody", data)
+		} else {
+			err := handler.VerificationRemoveHandler(data, hash, params)
+			if err != nil {
+				ll.Error("VerificationRemove handler encountered an error! |", "Error", err)
+			}
+		}
+	case protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD:
+		if handler.UserDataAddHandler == nil {
+			ll.Info("A profile was updated! |", "UserData", data)
+		} else {
+			err := handler.UserDataAddHandler(data, hash, params)
+			if err != nil {
+				ll.Error("UserDataAdd handler encountered an error! |", "Error", err)
+			}
+		}
+	case protos.MessageType_MESSAGE_TYPE_USERNAME_PROOF:
+		if handler.UsernameProofHandler == nil {
+			ll.Info("A username was proved! |", "Proof", data)
+		} else {
+			err := handler.UsernameProofHandler(data, hash, params)
+			if err != nil {
+				ll.Error("UsernameProof handler encountered an error! |", "Error", err)
+			}
+		}
+	case protos.MessageType_MESSAGE_TYPE_LINK_COMPACT_STATE:
+		if handler.LinkCompactStateHandler == nil {
+			ll.Info("Links were compacted! |", "Links", data)
+		} else {
+			err := handler.LinkCompactStateHandler(data, hash, params)
+			if err != nil {
+				ll.Error("LinkCompactState handler encountered an error! |", "Error", err)
 			}
 		}
+	default:
+		ll.Warn("Unhandled message type! |", "Type", data.Type)
 	}
 }
@@ -1,10 +1,18 @@
 package handlers_test
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
 	"github.com/noctisatrae/farseer/handlers"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestInitParams(t *testing.T) {
@@ -28,3 +36,314 @@ func TestInitParams(t *testing.T) {
 		"hello": "world",
 	}["hello"], params["hello"])
 }
+
+// Do single messages & bundles end up in the same handlers, with their metadata?
+func TestHandleMessageVariants(t *testing.T) {
+	cast := &protos.Message{
+		Data: &protos.MessageData{
+			Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD,
+			Fid:  10626,
+			Body: &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: "gm"}},
+		},
+		Hash: []byte{1, 2, 3},
+	}
+
+	single := &protos.GossipMessage{
+		PeerId:  []byte{9, 9},
+		Content: &protos.GossipMessage_Message{Message: cast},
+	}
+	bundle := &protos.GossipMessage{
+		PeerId: []byte{8, 8},
+		Content: &protos.GossipMessage_MessageBundle{MessageBundle: &protos.MessageBundle{
+			Hash:     []byte{7, 7},
+			Messages: []*protos.Message{cast, cast},
+		}},
+	}
+
+	msgs, meta := handlers.ExtractMessages(single)
+	assert.Len(t, msgs, 1)
+	assert.Equal(t, handlers.MessageMeta{PeerId: []byte{9, 9}}, meta)
+
+	msgs, meta = handlers.ExtractMessages(bundle)
+	assert.Len(t, msgs, 2)
+	assert.Equal(t, handlers.MessageMeta{BundleHash: []byte{7, 7}, PeerId: []byte{8, 8}}, meta)
+
+	bundleHashes := [][]byte{}
+	dummy := handlers.Handler{
+		CastAddHandler: func(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
+			bundleHashes = append(bundleHashes, params["bundleHash"].([]byte))
+			return nil
+		},
+	}
+
+	messages := make(chan *protos.GossipMessage, 2)
+	messages <- single
+	messages <- bundle
+	close(messages)
+	dummy.HandleMessages(messages, *log.Default(), map[string]interface{}{})
+
+	assert.Equal(t, [][]byte{nil, {7, 7}, {7, 7}}, bundleHashes)
+}
+
+// Does the gRPC server of a plugin decode its config & route the messages to its handlers?
+func TestPluginServer(t *testing.T) {
+	log.SetLevel(log.DebugLevel)
+
+	received := []string{}
+	s := handlers.NewPluginServer(handlers.FromHandler(handlers.Handler{
+		Name: "dummy",
+		InitHandler: func(params map[string]interface{}) error {
+			assert.Equal(t, []interface{}{int64(10626)}, params["FidsAllowed"])
+			return nil
+		},
+		CastAddHandler: func(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
+			received = append(received, data.GetCastAddBody().GetText())
+			assert.Equal(t, []byte{4, 2}, params["peerId"])
+			return nil
+		},
+	}), *log.Default())
+
+	res, err := s.Init(context.Background(), &protos.PluginInitRequest{Config: []byte("FidsAllowed = [10626]")})
+	assert.NoError(t, err)
+	assert.Equal(t, "dummy", res.Name)
+	assert.Equal(t, []protos.MessageType{protos.MessageType_MESSAGE_TYPE_CAST_ADD}, res.MessageTypes)
+
+	_, err = s.CastAdd(context.Background(), &protos.PluginMessage{
+		Message: &protos.Message{Data: &protos.MessageData{
+			Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD,
+			Body: &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: "gm"}},
+		}},
+		PeerId: []byte{4, 2},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gm"}, received)
+
+	// no handler for links: the message is ignored
+	_, err = s.LinkAdd(context.Background(), &protos.PluginMessage{Message: &protos.Message{Data: &protos.MessageData{}}})
+	assert.NoError(t, err)
+
+	_, err = s.CastAdd(context.Background(), &protos.PluginMessage{Message: &protos.Message{}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Are the profile updates, username proofs & compacted links routed to their handlers, after AnyMessageHandler?
+func TestHandleEveryType(t *testing.T) {
+	called := []string{}
+	record := func(name string) handlers.HandlerBehaviour {
+		return func(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
+			called = append(called, name)
+			return nil
+		}
+	}
+	dummy := handlers.Handler{
+		UserDataAddHandler:      record("UserDataAdd"),
+		UsernameProofHandler:    record("UsernameProof"),
+		LinkCompactStateHandler: record("LinkCompactState"),
+		AnyMessageHandler:       record("Any"),
+	}
+
+	for _, msgType := range []protos.MessageType{
+		protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD,
+		protos.MessageType_MESSAGE_TYPE_USERNAME_PROOF,
+		protos.MessageType_MESSAGE_TYPE_LINK_COMPACT_STATE,
+		protos.MessageType_MESSAGE_TYPE_CAST_ADD,
+	} {
+		m := &protos.Message{Data: &protos.MessageData{Type: msgType}}
+		dummy.HandleMessage(m, handlers.MessageMeta{}, *log.Default(), map[string]interface{}{})
+	}
+
+	assert.Equal(t, []string{"Any", "UserDataAdd", "Any", "UsernameProof", "Any", "LinkCompactState", "Any"}, called)
+
+	// a plugin with AnyMessageHandler receives every type of message
+	res, err := handlers.NewPluginServer(handlers.FromHandler(handlers.Handler{AnyMessageHandler: record("Any")}), *log.Default()).
+		Init(context.Background(), &protos.PluginInitRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, res.MessageTypes, 12)
+}
+
+// Does the handler of the type still run when AnyMessageHandler fails, with both errors reported?
+func TestAnyMessageHandlerError(t *testing.T) {
+	called := []string{}
+	fail := func(name string) handlers.HandlerBehaviour {
+		return func(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
+			called = append(called, name)
+			return fmt.Errorf("%s failed", name)
+		}
+	}
+	p := handlers.FromHandler(handlers.Handler{AnyMessageHandler: fail("Any"), CastAddHandler: fail("CastAdd")})
+	assert.NoError(t, p.Configure(nil))
+	assert.NoError(t, p.Init(context.Background()))
+
+	m := &protos.Message{Data: &protos.MessageData{Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD}}
+	err := p.HandleMessage(context.Background(), m, handlers.MessageMeta{})
+	assert.Equal(t, []string{"Any", "CastAdd"}, called)
+	assert.ErrorContains(t, err, "Any failed")
+	assert.ErrorContains(t, err, "CastAdd failed")
+}
+
+// Are removals given to their own handler, not the one of the add?
+func TestRemoveRouting(t *testing.T) {
+	called := []string{}
+	record := func(name string) handlers.HandlerBehaviour {
+		return func(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
+			called = append(called, name)
+			return nil
+		}
+	}
+	dummy := handlers.Handler{
+		LinkAddHandler:            record("LinkAdd"),
+		LinkRemoveHandler:         record("LinkRemove"),
+		VerificationAddHandler:    record("VerificationAdd"),
+		VerificationRemoveHandler: record("VerificationRemove"),
+	}
+
+	for _, msgType := range []protos.MessageType{
+		protos.MessageType_MESSAGE_TYPE_LINK_ADD,
+		protos.MessageType_MESSAGE_TYPE_LINK_REMOVE,
+		protos.MessageType_MESSAGE_TYPE_VERIFICATION_ADD_ETH_ADDRESS,
+		protos.MessageType_MESSAGE_TYPE_VERIFICATION_REMOVE,
+	} {
+		m := &protos.Message{Data: &protos.MessageData{Type: msgType}}
+		dummy.HandleMessage(m, handlers.MessageMeta{}, *log.Default(), map[string]interface{}{})
+	}
+
+	assert.Equal(t, []string{"LinkAdd", "LinkRemove", "VerificationAdd", "VerificationRemove"}, called)
+}
+
+type dummyConfig struct {
+	Greeting string
+	Fids     []uint64
+}
+
+type dummyPlugin struct {
+	config   dummyConfig
+	ready    bool
+	closed   bool
+	received []string
+	// how many times the plugin says it's busy before taking a message
+	busy int
+}
+
+func (p *dummyPlugin) Name() string { return "dummy" }
+
+func (p *dummyPlugin) Configure(raw []byte) error {
+	return handlers.Decode(raw, &p.config)
+}
+
+func (p *dummyPlugin) Init(ctx context.Context) error {
+	p.ready = true
+	return nil
+}
+
+func (p *dummyPlugin) HandleMessage(ctx context.Context, m *protos.Message, meta handlers.MessageMeta) error {
+	if m.Data.Fid == 0 {
+		panic("no fid")
+	}
+	if p.busy > 0 {
+		p.busy--
+		return fmt.Errorf("queue is full: %w", handlers.ErrBusy)
+	}
+	p.received = append(p.received, fmt.Sprintf("%s %d", p.config.Greeting, m.Data.Fid))
+	return nil
+}
+
+func (p *dummyPlugin) Close(ctx context.Context) error {
+	p.closed = true
+	return nil
+}
+
+// Is the config decoded into the plugin's struct & are typos refused?
+func TestDecode(t *testing.T) {
+	var conf dummyConfig
+	assert.NoError(t, handlers.Decode([]byte("Greeting = 'gm'\nFids = [10626]"), &conf))
+	assert.Equal(t, dummyConfig{Greeting: "gm", Fids: []uint64{10626}}, conf)
+
+	assert.Error(t, handlers.Decode([]byte("Greting = 'gm'"), &conf))
+	assert.Error(t, handlers.Decode([]byte("Fids = 'all'"), &conf))
+}
+
+// Does a v2 plugin get configured, initialized, fed & closed?
+func TestRunPlugin(t *testing.T) {
+	log.SetLevel(log.DebugLevel)
+
+	messages := make(chan *protos.GossipMessage, 2)
+	messages <- &protos.GossipMessage{Content: &protos.GossipMessage_Message{Message: &protos.Message{Data: &protos.MessageData{Fid: 10626}}}}
+	messages <- &protos.GossipMessage{Content: &protos.GossipMessage_MessageBundle{MessageBundle: &protos.MessageBundle{
+		Messages: []*protos.Message{{Data: &protos.MessageData{Fid: 2}}, {}},
+	}}}
+	close(messages)
+
+	p := &dummyPlugin{}
+	err := handlers.RunPlugin(context.Background(), p, []byte("Greeting = 'gm'"), messages, *log.Default())
+	assert.NoError(t, err)
+	assert.True(t, p.ready)
+	assert.True(t, p.closed)
+	assert.Equal(t, []string{"gm 10626", "gm 2"}, p.received)
+
+	err = handlers.RunPlugin(context.Background(), &dummyPlugin{}, []byte("Unknown = 1"), messages, *log.Default())
+	assert.Error(t, err)
+}
+
+// Is a message handed again to a busy plugin instead of being counted as failed?
+func TestPluginBusy(t *testing.T) {
+	messages := make(chan *protos.GossipMessage, 1)
+	messages <- &protos.GossipMessage{Content: &protos.GossipMessage_Message{Message: &protos.Message{Data: &protos.MessageData{Fid: 10626}}}}
+	close(messages)
+
+	p := &dummyPlugin{busy: 2}
+	err := handlers.RunPlugin(context.Background(), p, []byte("Greeting = 'gm'"), messages, *log.Default())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, p.busy)
+	assert.Equal(t, []string{"gm 10626"}, p.received)
+
+	p = &dummyPlugin{busy: 1}
+	s := handlers.NewPluginServer(p, *log.Default())
+	_, err = s.Init(context.Background(), &protos.PluginInitRequest{Config: []byte("Greeting = 'gm'")})
+	assert.NoError(t, err)
+	_, err = s.CastAdd(context.Background(), &protos.PluginMessage{Message: &protos.Message{Data: &protos.MessageData{Fid: 10626}}})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "the relay is told to send it again")
+}
+
+// Is a panicking handler reported as an error instead of taking the plugin down?
+func TestPluginPanic(t *testing.T) {
+	messages := make(chan *protos.GossipMessage, 2)
+	messages <- &protos.GossipMessage{Content: &protos.GossipMessage_Message{Message: &protos.Message{Data: &protos.MessageData{}}}}
+	messages <- &protos.GossipMessage{Content: &protos.GossipMessage_Message{Message: &protos.Message{Data: &protos.MessageData{Fid: 10626}}}}
+	close(messages)
+
+	p := &dummyPlugin{}
+	err := handlers.RunPlugin(context.Background(), p, []byte("Greeting = 'gm'"), messages, *log.Default())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gm 10626"}, p.received)
+
+	s := handlers.NewPluginServer(&dummyPlugin{}, *log.Default())
+	_, err = s.Init(context.Background(), &protos.PluginInitRequest{Config: []byte("Greeting = 'gm'")})
+	assert.NoError(t, err)
+	_, err = s.CastAdd(context.Background(), &protos.PluginMessage{Message: &protos.Message{Data: &protos.MessageData{}}})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	_, err = s.CastAdd(context.Background(), &protos.PluginMessage{Message: &protos.Message{Data: &protos.MessageData{Fid: 10626}}})
+	assert.NoError(t, err)
+}
+
+// Are the messages encoded as the plugins' Encoding says & are the other encodings refused?
+func TestEncodeMessage(t *testing.T) {
+	m := &protos.Message{Hash: []byte{1, 2, 3}, Data: &protos.MessageData{Fid: 10626}}
+
+	assert.NoError(t, handlers.CheckEncoding(handlers.EncodingJSON))
+	assert.Error(t, handlers.CheckEncoding("avro"))
+
+	encoded, err := handlers.EncodeMessage(m, handlers.EncodingJSON)
+	assert.NoError(t, err)
+	assert.Contains(t, string(encoded), `"fid":"10626"`)
+
+	encoded, err = handlers.EncodeMessage(m, handlers.EncodingProtobuf)
+	assert.NoError(t, err)
+	decoded := &protos.Message{}
+	assert.NoError(t, proto.Unmarshal(encoded, decoded))
+	assert.Equal(t, uint64(10626), decoded.Data.Fid)
+
+	messageTypes := handlers.AllMessageTypes()
+	assert.NotContains(t, messageTypes, protos.MessageType_MESSAGE_TYPE_NONE)
+	assert.Equal(t, protos.MessageType_MESSAGE_TYPE_CAST_ADD, messageTypes[0])
+	assert.Len(t, messageTypes, len(protos.MessageType_name)-1)
+}
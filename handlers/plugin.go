@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The environment variable holding the path of the Unix socket a plugin must listen on. It's set by the relay when
+// it launches the plugin.
+const PluginSocketEnv = "FARSEER_PLUGIN_SOCKET"
+
+// Runs a handler as an out-of-process plugin: it serves the HandlerPlugin service on the socket given by the relay
+// until it receives SIGINT or SIGTERM. Your plugin only needs a main function calling it:
+//
+//	func main() {
+//		if err := handlers.Serve(PluginHandler); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+func Serve(handler Handler) error {
+	return ServePlugin(FromHandler(handler))
+}
+
+// The main function of a plugin using the v2 API: it serves the plugin & exits when it stops with an error.
+//
+//	func main() {
+//		handlers.Main(Plugin)
+//	}
+func Main(plugin Plugin) {
+	if err := ServePlugin(plugin); err != nil {
+		log.Fatal("The plugin stopped! |", "Error", err)
+	}
+}
+
+// Same as Serve, for the plugins using the v2 API.
+func ServePlugin(plugin Plugin) error {
+	socketPath := os.Getenv(PluginSocketEnv)
+	if socketPath == "" {
+		return errors.New(PluginSocketEnv + " isn't set: plugins are launched by the relay, add a Command to their [handlers.<name>] table")
+	}
+
+	// a previous instance of the plugin may have left its socket behind
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+
+	ll := log.WithPrefix(plugin.Name())
+	grpcServer := grpc.NewServer()
+	pluginServer := NewPluginServer(plugin, *ll)
+	protos.RegisterHandlerPluginServer(grpcServer, pluginServer)
+
+	stopCh := make(chan os.Signal, 1)
+	signal.Notify(stopCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-stopCh
+		ll.Info("Stopping the plugin!")
+		grpcServer.GracefulStop()
+	}()
+
+	ll.Info("Plugin is listening! |", "Socket", socketPath)
+	err = grpcServer.Serve(listener)
+	pluginServer.Close()
+	return err
+}
+
+// Serves a Plugin over gRPC: it's what Serve registers, exposed so plugins can be tested in-process.
+type PluginServer struct {
+	protos.UnimplementedHandlerPluginServer
+
+	plugin Plugin
+	ll     log.Logger
+
+	// the plugin holds its own state, so it handles one message at a time
+	mu          sync.Mutex
+	initialized bool
+}
+
+func NewPluginServer(plugin Plugin, ll log.Logger) *PluginServer {
+	return &PluginServer{
+		plugin: plugin,
+		ll:     ll,
+	}
+}
+
+// Configures then initializes the plugin.
+func (s *PluginServer) Init(ctx context.Context, req *protos.PluginInitRequest) (*protos.PluginInitResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.plugin.Configure(req.Config); err != nil {
+		s.ll.Error("Couldn't configure the plugin! |", "Name", s.plugin.Name(), "Error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.plugin.Init(ctx); err != nil {
+		s.ll.Error("A handler encountered a problem! |", "Name", s.plugin.Name(), "Error", err)
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	s.initialized = true
+
+	messageTypes := []protos.MessageType{}
+	if filter, ok := s.plugin.(MessageTypesFilter); ok {
+		messageTypes = filter.MessageTypes()
+	} else {
+		for msgType := range (Handler{}).behaviours() {
+			messageTypes = append(messageTypes, msgType)
+		}
+	}
+
+	return &protos.PluginInitResponse{Name: s.plugin.Name(), MessageTypes: messageTypes}, nil
+}
+
+// Closes the plugin if it was initialized.
+func (s *PluginServer) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.initialized {
+		return
+	}
+	if err := s.plugin.Close(context.Background()); err != nil {
+		s.ll.Error("Couldn't close the plugin! |", "Name", s.plugin.Name(), "Error", err)
+	}
+	s.initialized = false
+}
+
+func (s *PluginServer) CastAdd(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	return s.handle(ctx, req)
+}
+
+func (s *PluginServer) CastRemove(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	return s.handle(ctx, req)
+}
+
+func (s *PluginServer) FrameAction(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	return s.handle(ctx, req)
+}
+
+func (s *PluginServer) ReactionAdd(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	return s.handle(ctx, req)
+}
+
+func (s *PluginServer) ReactionRemove(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	return s.handle(ctx, req)
+}
+
+func (s *PluginServer) LinkAdd(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	return s.handle(ctx, req)
+}
+
+func (s *PluginServer) LinkRemove(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	return s.handle(ctx, req)
+}
+
+func (s *PluginServer) VerificationAdd(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	return s.handle(ctx, req)
+}
+
+func (s *PluginServer) VerificationRemove(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	return s.handle(ctx, req)
+}
+
+func (s *PluginServer) UserDataAdd(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	return s.handle(ctx, req)
+}
+
+func (s *PluginServer) UsernameProof(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	return s.handle(ctx, req)
+}
+
+func (s *PluginServer) LinkCompactState(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	return s.handle(ctx, req)
+}
+
+func (s *PluginServer) handle(ctx context.Context, req *protos.PluginMessage) (*protos.PluginResponse, error) {
+	m := req.GetMessage()
+	if m.GetData() == nil {
+		return nil, status.Error(codes.InvalidArgument, "the message has no data")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.initialized {
+		return nil, status.Error(codes.FailedPrecondition, "the plugin isn't initialized")
+	}
+
+	if err := handleMessage(ctx, s.plugin, m, MessageMeta{BundleHash: req.BundleHash, PeerId: req.PeerId}); errors.Is(err, errPanicked) {
+		s.ll.Error("A handler panicked! |", "Name", s.plugin.Name(), "Error", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	} else if errors.Is(err, ErrBusy) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	return &protos.PluginResponse{}, nil
+}
+
+// The handler of every type of message, nil when the plugin doesn't define it.
+func (handler Handler) behaviours() map[protos.MessageType]HandlerBehaviour {
+	return map[protos.MessageType]HandlerBehaviour{
+		protos.MessageType_MESSAGE_TYPE_CAST_ADD:                     handler.CastAddHandler,
+		protos.MessageType_MESSAGE_TYPE_CAST_REMOVE:                  handler.CastRemoveHandler,
+		protos.MessageType_MESSAGE_TYPE_FRAME_ACTION:                 handler.FrameActionHandler,
+		protos.MessageType_MESSAGE_TYPE_REACTION_ADD:                 handler.ReactionAddHandler,
+		protos.MessageType_MESSAGE_TYPE_REACTION_REMOVE:              handler.ReactionRemoveHandler,
+		protos.MessageType_MESSAGE_TYPE_LINK_ADD:                     handler.LinkAddHandler,
+		protos.MessageType_MESSAGE_TYPE_LINK_REMOVE:                  handler.LinkRemoveHandler,
+		protos.MessageType_MESSAGE_TYPE_VERIFICATION_ADD_ETH_ADDRESS: handler.VerificationAddHandler,
+		protos.MessageType_MESSAGE_TYPE_VERIFICATION_REMOVE:          handler.VerificationRemoveHandler,
+		protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD:                handler.UserDataAddHandler,
+		protos.MessageType_MESSAGE_TYPE_USERNAME_PROOF:               handler.UsernameProofHandler,
+		protos.MessageType_MESSAGE_TYPE_LINK_COMPACT_STATE:           handler.LinkCompactStateHandler,
+	}
+}
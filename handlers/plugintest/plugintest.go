@@ -0,0 +1,26 @@
+// Helpers to test the plugins in-process, without a relay.
+package plugintest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/noctisatrae/farseer/handlers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Configures & initializes the plugin, stopping the test if it fails. The plugin is closed at the end of the test.
+func Start(t testing.TB, plugin handlers.Plugin, config string) {
+	t.Helper()
+
+	err := plugin.Configure([]byte(config))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = plugin.Init(context.Background())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { plugin.Close(context.Background()) })
+}
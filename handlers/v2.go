@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	"github.com/pelletier/go-toml/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// The v2 handler API: the plugin decodes its config into its own struct & holds its state (DB connections...) on
+// its receiver instead of a params map. Here's the shape of a plugin:
+//
+//	type Config struct {
+//		DbAddress string
+//	}
+//
+//	type MyPlugin struct {
+//		config Config
+//		conn   *pgx.Conn
+//	}
+//
+//	func (p *MyPlugin) Name() string { return "MyPlugin" }
+//
+//	func (p *MyPlugin) Configure(raw []byte) error {
+//		return handlers.Decode(raw, &p.config)
+//	}
+//
+//	func (p *MyPlugin) Init(ctx context.Context) (err error) {
+//		p.conn, err = pgx.Connect(ctx, p.config.DbAddress)
+//		return err
+//	}
+//
+// Messages are handled one at a time, so the plugin doesn't need to protect its state.
+type Plugin interface {
+	// Used for contextualization in logs.
+	Name() string
+	// Receives the [handlers.<name>] table of config.toml, without the keys read by the relay, encoded as TOML.
+	Configure(raw []byte) error
+	// Run once before the first message. No message is handled if it fails.
+	Init(ctx context.Context) error
+	// Run for every message. Its data is always set.
+	HandleMessage(ctx context.Context, m *protos.Message, meta MessageMeta) error
+	// Run when the relay stops sending messages.
+	Close(ctx context.Context) error
+}
+
+// Implemented by the plugins that only handle some types of messages, so the others aren't sent to them.
+type MessageTypesFilter interface {
+	MessageTypes() []protos.MessageType
+}
+
+// Decodes the config of a plugin into a struct. Unknown keys are refused so typos in config.toml are caught early.
+func Decode(raw []byte, v interface{}) error {
+	decoder := toml.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		if strictErr, ok := err.(*toml.StrictMissingError); ok {
+			return fmt.Errorf("invalid config: %s", strictErr.String())
+		}
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// How the plugins publishing whole messages encode them, set by their Encoding key.
+const (
+	EncodingProtobuf = "protobuf"
+	EncodingJSON     = "json"
+)
+
+// Refuses the encodings that aren't supported, to be called from Configure.
+func CheckEncoding(encoding string) error {
+	if encoding != EncodingProtobuf && encoding != EncodingJSON {
+		return fmt.Errorf("invalid Encoding %q, expected %q or %q", encoding, EncodingProtobuf, EncodingJSON)
+	}
+	return nil
+}
+
+// Encodes the message as protobuf, or as JSON with protojson.
+func EncodeMessage(m *protos.Message, encoding string) ([]byte, error) {
+	if encoding == EncodingJSON {
+		return protojson.Marshal(m)
+	}
+	return proto.Marshal(m)
+}
+
+// Every type of message, sorted. The plugins that want them all return it from MessageTypes, the relay only sends
+// them the ones matching their Filter.
+func AllMessageTypes() []protos.MessageType {
+	messageTypes := []protos.MessageType{}
+	for value := range protos.MessageType_name {
+		if value != int32(protos.MessageType_MESSAGE_TYPE_NONE) {
+			messageTypes = append(messageTypes, protos.MessageType(value))
+		}
+	}
+	slices.Sort(messageTypes)
+	return messageTypes
+}
+
+// Wraps a v1 Handler so it can be used wherever a Plugin is expected. The config is decoded into the params map
+// given to its behaviours.
+func FromHandler(handler Handler) Plugin {
+	return &handlerPlugin{handler: handler, params: map[string]interface{}{}}
+}
+
+type handlerPlugin struct {
+	handler Handler
+	params  map[string]interface{}
+}
+
+func (p *handlerPlugin) Name() string {
+	return p.handler.Name
+}
+
+func (p *handlerPlugin) Configure(raw []byte) error {
+	return toml.Unmarshal(raw, &p.params)
+}
+
+func (p *handlerPlugin) Init(ctx context.Context) error {
+	if p.handler.InitHandler == nil {
+		return nil
+	}
+	return p.handler.InitHandler(p.params)
+}
+
+func (p *handlerPlugin) HandleMessage(ctx context.Context, m *protos.Message, meta MessageMeta) error {
+	behaviour := p.handler.behaviours()[m.Data.Type]
+	if behaviour == nil && p.handler.AnyMessageHandler == nil {
+		return nil
+	}
+
+	p.params["bundleHash"] = meta.BundleHash
+	p.params["peerId"] = meta.PeerId
+
+	// Like the v1 path, a failing AnyMessageHandler doesn't keep the handler of the type from running.
+	var anyErr, behaviourErr error
+	if p.handler.AnyMessageHandler != nil {
+		anyErr = p.handler.AnyMessageHandler(m.Data, m.Hash, p.params)
+	}
+	if behaviour != nil {
+		behaviourErr = behaviour(m.Data, m.Hash, p.params)
+	}
+	return errors.Join(anyErr, behaviourErr)
+}
+
+func (p *handlerPlugin) Close(ctx context.Context) error {
+	return nil
+}
+
+func (p *handlerPlugin) MessageTypes() []protos.MessageType {
+	messageTypes := []protos.MessageType{}
+	for msgType, behaviour := range p.handler.behaviours() {
+		if behaviour != nil || p.handler.AnyMessageHandler != nil {
+			messageTypes = append(messageTypes, msgType)
+		}
+	}
+	return messageTypes
+}
+
+// Runs a plugin in the relay's process: it's configured & initialized, then handles the messages until the channel
+// is closed or the context is cancelled.
+func RunPlugin(ctx context.Context, plugin Plugin, config []byte, messages chan *protos.GossipMessage, ll log.Logger) error {
+	if err := plugin.Configure(config); err != nil {
+		return err
+	}
+	if err := plugin.Init(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if err := plugin.Close(context.Background()); err != nil {
+			ll.Error("Couldn't close the plugin! |", "Name", plugin.Name(), "Error", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msgB, ok := <-messages:
+			if !ok {
+				return nil
+			}
+
+			msgs, meta := ExtractMessages(msgB)
+			for _, m := range msgs {
+				if m.GetData() == nil {
+					ll.Warn("Received a message without data! |", "Hash", m.GetHash())
+					continue
+				}
+				if err := handleBusy(ctx, plugin, m, meta); err != nil {
+					ll.Error("A handler encountered an error! |", "Name", plugin.Name(), "Type", m.Data.Type, "Error", err)
+				}
+			}
+		}
+	}
+}
+
+// Returned by HandleMessage, wrapped or not, when the plugin can't take the message yet, e.g. because its queue is
+// full while its database is down. The message is handled again a bit later instead of being counted as failed, so
+// the relay slows down without the handler having to block.
+var ErrBusy = errors.New("the plugin is busy")
+
+// Delays between the attempts to hand a message to a busy plugin.
+const (
+	busyMinBackoff = 100 * time.Millisecond
+	busyMaxBackoff = 5 * time.Second
+)
+
+var errPanicked = errors.New("the plugin panicked")
+
+// Handles the message until the plugin isn't busy anymore or the context is cancelled.
+func handleBusy(ctx context.Context, plugin Plugin, m *protos.Message, meta MessageMeta) error {
+	backoff := busyMinBackoff
+	for {
+		err := handleMessage(ctx, plugin, m, meta)
+		if !errors.Is(err, ErrBusy) {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff = min(backoff*2, busyMaxBackoff)
+	}
+}
+
+// Runs the handler of the plugin, turning a panic into an error so a bug in a plugin can't take its process down.
+func handleMessage(ctx context.Context, plugin Plugin, m *protos.Message, meta MessageMeta) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", errPanicked, r)
+		}
+	}()
+	return plugin.HandleMessage(ctx, m, meta)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	handler "github.com/noctisatrae/farseer/handlers"
+	protos "github.com/noctisatrae/farseer/protos"
+	utils "github.com/noctisatrae/farseer/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const defaultDeliveryTimeout = 30000 // ms
+
+// The [handlers.kafka] table of config.toml.
+//
+// ```toml
+// [handlers.kafka]
+// Brokers = ["redpanda:9092"] # required
+// Topic = "farseer.messages" # optional: where the messages without a route in Topics are produced
+// Encoding = "protobuf" # optional: protobuf or json, how the message is encoded in the record
+// DeliveryTimeout = 30000 # optional: how long a record is retried before it's counted as failed, in milliseconds
+// AutoCreateTopics = false # optional: ask the brokers to create the topics that don't exist
+// MetricsAddress = ":9101" # optional: serves the metrics of the producer on /metrics
+//
+// [handlers.kafka.Topics] # optional: the topic of each type of message, named like in the MessageType enum
+// cast_add = "farseer.casts"
+// cast_remove = "farseer.casts"
+// ```
+type Config struct {
+	Brokers          []string
+	Topic            string
+	Topics           map[string]string
+	Encoding         string
+	DeliveryTimeout  uint
+	AutoCreateTopics bool
+	MetricsAddress   string
+}
+
+// Produces every message to a Kafka (or Redpanda) topic. The key of a record is the fid of the user who sent the
+// message, so the messages of a user land on the same partition & keep their order. The producer is idempotent: a
+// record retried after a network error isn't written twice.
+type Kafka struct {
+	config  Config
+	client  *kgo.Client
+	metrics *producerMetrics
+	server  *http.Server
+
+	// records outlive the message that produced them, they're only cancelled when the plugin closes
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// What the producer did, labelled by topic.
+type producerMetrics struct {
+	registry *prometheus.Registry
+	produced *prometheus.CounterVec
+	failed   *prometheus.CounterVec
+}
+
+func newProducerMetrics() *producerMetrics {
+	m := &producerMetrics{
+		registry: prometheus.NewRegistry(),
+		produced: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "farseer_kafka_records_produced_total",
+			Help: "Records acknowledged by the brokers.",
+		}, []string{"topic"}),
+		failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "farseer_kafka_delivery_failures_total",
+			Help: "Records that couldn't be delivered before the delivery timeout.",
+		}, []string{"topic"}),
+	}
+	m.registry.MustRegister(m.produced, m.failed)
+
+	return m
+}
+
+func (k *Kafka) Name() string {
+	return "Kafka"
+}
+
+func (k *Kafka) Configure(raw []byte) error {
+	config := Config{
+		Topic:           "farseer.messages",
+		Encoding:        handler.EncodingProtobuf,
+		DeliveryTimeout: defaultDeliveryTimeout,
+	}
+	if err := handler.Decode(raw, &config); err != nil {
+		return err
+	}
+	if len(config.Brokers) == 0 {
+		return errors.New("no Brokers were provided, so no connection can be made to Kafka")
+	}
+	if err := handler.CheckEncoding(config.Encoding); err != nil {
+		return err
+	}
+	if config.DeliveryTimeout < 1000 {
+		return errors.New("DeliveryTimeout can't be less than 1000 ms")
+	}
+	for name := range config.Topics {
+		if _, ok := protos.MessageType_value["MESSAGE_TYPE_"+strings.ToUpper(name)]; !ok {
+			return fmt.Errorf("invalid type of message %q in Topics, expected a name like cast_add", name)
+		}
+	}
+
+	k.config = config
+	return nil
+}
+
+// Connects to the brokers & serves the metrics.
+func (k *Kafka) Init(ctx context.Context) error {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(k.config.Brokers...),
+		kgo.ClientID("farseer"),
+		// the idempotent producer needs every in-sync replica to acknowledge the records
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+		// hashes the keys like the Java client, so the partition of a fid is the same for every producer
+		kgo.RecordPartitioner(kgo.StickyKeyPartitioner(nil)),
+		kgo.RecordDeliveryTimeout(time.Duration(k.config.DeliveryTimeout) * time.Millisecond),
+	}
+	if k.config.AutoCreateTopics {
+		opts = append(opts, kgo.AllowAutoTopicCreation())
+	}
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return err
+	}
+	if err := client.Ping(ctx); err != nil {
+		client.Close()
+		return err
+	}
+
+	k.client = client
+	k.metrics = newProducerMetrics()
+	k.ctx, k.cancel = context.WithCancel(context.Background())
+
+	if k.config.MetricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(k.metrics.registry, promhttp.HandlerOpts{}))
+		k.server = &http.Server{Addr: k.config.MetricsAddress, Handler: mux}
+		go func() {
+			if err := k.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error("Couldn't serve the metrics! |", "Address", k.config.MetricsAddress, "Error", err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// Waits for the records still buffered to be delivered, then disconnects.
+func (k *Kafka) Close(ctx context.Context) error {
+	if k.client == nil {
+		return nil
+	}
+
+	err := k.client.Flush(ctx)
+	k.cancel()
+	k.client.Close()
+	k.client = nil
+	if k.server != nil {
+		k.server.Close()
+	}
+	return err
+}
+
+// The topic the messages of this type are produced to.
+func (k *Kafka) Topic(msgType protos.MessageType) string {
+	name := strings.ToLower(strings.TrimPrefix(msgType.String(), "MESSAGE_TYPE_"))
+	if topic, ok := k.config.Topics[name]; ok {
+		return topic
+	}
+	return k.config.Topic
+}
+
+// Buffers the message, it's delivered in the background. The failures are logged & counted, not returned, since
+// the relay has moved on by then.
+func (k *Kafka) HandleMessage(ctx context.Context, m *protos.Message, meta handler.MessageMeta) error {
+	encoded, err := handler.EncodeMessage(m, k.config.Encoding)
+	if err != nil {
+		return err
+	}
+
+	record := &kgo.Record{
+		Topic: k.Topic(m.Data.Type),
+		Key:   []byte(strconv.FormatUint(m.Data.Fid, 10)),
+		Value: encoded,
+		Headers: []kgo.RecordHeader{
+			{Key: "hash", Value: []byte(utils.BytesToHex(m.Hash))},
+			{Key: "type", Value: []byte(m.Data.Type.String())},
+		},
+	}
+
+	// blocks when too many records are buffered, slowing the relay down instead of dropping messages
+	k.client.Produce(k.ctx, record, func(r *kgo.Record, err error) {
+		if err != nil {
+			k.metrics.failed.WithLabelValues(r.Topic).Inc()
+			log.Error("Couldn't deliver the message! |", "Topic", r.Topic, "Hash", utils.BytesToHex(m.Hash), "Error", err)
+			return
+		}
+		k.metrics.produced.WithLabelValues(r.Topic).Inc()
+	})
+
+	return nil
+}
+
+// Every type of message, the relay only sends the ones matching the Filter of the plugin.
+func (k *Kafka) MessageTypes() []protos.MessageType {
+	return handler.AllMessageTypes()
+}
+
+// Served by main, or looked up by the relay when the plugin is built as a shared library.
+var Plugin handler.Plugin = &Kafka{}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	handler "github.com/noctisatrae/farseer/handlers"
+	"github.com/noctisatrae/farseer/handlers/plugintest"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"google.golang.org/protobuf/proto"
+)
+
+// Configures & connects the plugin to an in-process broker with the given topics.
+func initPlugin(t *testing.T, config string, topics ...string) (*Kafka, *kfake.Cluster) {
+	log.SetLevel(log.DebugLevel)
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(3, topics...))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(cluster.Close)
+
+	k := &Kafka{}
+	plugintest.Start(t, k, "Brokers = ['"+strings.Join(cluster.ListenAddrs(), "', '")+"']\n"+config)
+
+	return k, cluster
+}
+
+func message(msgType protos.MessageType, fid uint64) *protos.Message {
+	return &protos.Message{
+		Hash: []byte{1, 2, 3},
+		Data: &protos.MessageData{Type: msgType, Fid: fid, Timestamp: 100},
+	}
+}
+
+// Reads every record of a topic.
+func consume(t *testing.T, cluster *kfake.Cluster, topic string, count int) []*kgo.Record {
+	client, err := kgo.NewClient(kgo.SeedBrokers(cluster.ListenAddrs()...), kgo.ConsumeTopics(topic))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	records := []*kgo.Record{}
+	for len(records) < count {
+		fetches := client.PollFetches(ctx)
+		if ctx.Err() != nil {
+			t.Fatalf("only %d records were consumed from %s", len(records), topic)
+		}
+		records = append(records, fetches.Records()...)
+	}
+	return records
+}
+
+func TestConfigure(t *testing.T) {
+	k := &Kafka{}
+	assert.Error(t, k.Configure([]byte("")), "Brokers are required")
+	assert.Error(t, k.Configure([]byte("Brokers = ['localhost:9092']\nEncoding = 'avro'")))
+	assert.Error(t, k.Configure([]byte("Brokers = ['localhost:9092']\nDeliveryTimeout = 200")))
+	assert.Error(t, k.Configure([]byte("Brokers = ['localhost:9092']\n[Topics]\ncasts = 'farseer.casts'")), "unknown type of message")
+
+	assert.NoError(t, k.Configure([]byte("Brokers = ['localhost:9092']\n[Topics]\ncast_add = 'farseer.casts'")))
+	assert.Equal(t, "farseer.casts", k.Topic(protos.MessageType_MESSAGE_TYPE_CAST_ADD))
+	assert.Equal(t, "farseer.messages", k.Topic(protos.MessageType_MESSAGE_TYPE_LINK_ADD))
+}
+
+// Are the messages routed by type & keyed by fid?
+func TestProduce(t *testing.T) {
+	k, cluster := initPlugin(t, "[Topics]\ncast_add = 'farseer.casts'", "farseer.casts", "farseer.messages")
+
+	ctx := context.Background()
+	assert.NoError(t, k.HandleMessage(ctx, message(protos.MessageType_MESSAGE_TYPE_CAST_ADD, 10626), handler.MessageMeta{}))
+	assert.NoError(t, k.HandleMessage(ctx, message(protos.MessageType_MESSAGE_TYPE_CAST_ADD, 10626), handler.MessageMeta{}))
+	assert.NoError(t, k.HandleMessage(ctx, message(protos.MessageType_MESSAGE_TYPE_LINK_ADD, 3), handler.MessageMeta{}))
+	assert.NoError(t, k.client.Flush(ctx))
+
+	casts := consume(t, cluster, "farseer.casts", 2)
+	assert.Equal(t, "10626", string(casts[0].Key))
+	assert.Equal(t, casts[0].Partition, casts[1].Partition, "the messages of a user are on the same partition")
+	decoded := &protos.Message{}
+	assert.NoError(t, proto.Unmarshal(casts[0].Value, decoded))
+	assert.Equal(t, uint64(10626), decoded.Data.Fid)
+	assert.Equal(t, "0x010203", string(casts[0].Headers[0].Value))
+
+	others := consume(t, cluster, "farseer.messages", 1)
+	assert.Equal(t, "3", string(others[0].Key))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(k.metrics.produced.WithLabelValues("farseer.casts")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(k.metrics.produced.WithLabelValues("farseer.messages")))
+}
+
+// Are the records that can't be delivered counted?
+func TestDeliveryFailure(t *testing.T) {
+	k, _ := initPlugin(t, "DeliveryTimeout = 1000", "farseer.messages")
+	k.config.Topic = "missing"
+
+	assert.NoError(t, k.HandleMessage(context.Background(), message(protos.MessageType_MESSAGE_TYPE_CAST_ADD, 10626), handler.MessageMeta{}))
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(k.metrics.failed.WithLabelValues("missing")) == 1
+	}, 10*time.Second, 20*time.Millisecond)
+}
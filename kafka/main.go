@@ -0,0 +1,15 @@
+package main
+
+import (
+	handler "github.com/noctisatrae/farseer/handlers"
+)
+
+// Runs the plugin in its own process, launched by the relay with:
+//
+// ```toml
+// [handlers.kafka]
+// Command = ["compiled_handlers/farseer-kafka"]
+// ```
+func main() {
+	handler.Main(Plugin)
+}
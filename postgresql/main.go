@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/noctisatrae/farseer/config"
+	handler "github.com/noctisatrae/farseer/handlers"
+	"github.com/noctisatrae/farseer/postgresql/migrations"
+
+	"github.com/charmbracelet/log"
+	"github.com/jackc/pgx/v5"
+)
+
+const usage = `Usage:
+  farseer-pg                                      run the plugin, launched by the relay
+  farseer-pg migrate [flags] up|down|status       manage the schema of the database
+
+Flags:
+`
+
+// Runs the plugin in its own process, launched by the relay with:
+//
+// ```toml
+// [handlers.postgresql]
+// Command = ["compiled_handlers/farseer-pg"]
+// ```
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(os.Args[2:]); err != nil {
+			log.Fatal("Couldn't migrate the database! |", "Error", err)
+		}
+		return
+	}
+
+	handler.Main(Plugin)
+}
+
+func migrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dbAddress := flags.String("db", "", "address of the database, defaults to the DbAddress of config.toml")
+	configPath := flags.String("config", "config.toml", "config of the hub to read the DbAddress from")
+	steps := flags.Int("steps", 1, "how many migrations are reverted by down")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if *dbAddress == "" {
+		conf, err := config.Load(*configPath)
+		if err != nil {
+			return err
+		}
+		address, _ := conf.GetParams("postgresql")["DbAddress"].(string)
+		if address == "" {
+			return errors.New("no DbAddress in [handlers.postgresql], use -db")
+		}
+		*dbAddress = address
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, *dbAddress)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	switch flags.Arg(0) {
+	case "up":
+		applied, err := migrations.Up(ctx, conn)
+		for _, migration := range applied {
+			log.Info("Applied migration! |", "Version", migration.Version, "Name", migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			log.Info("The database is up to date!")
+		}
+		return err
+	case "down":
+		reverted, err := migrations.Down(ctx, conn, *steps)
+		for _, migration := range reverted {
+			log.Info("Reverted migration! |", "Version", migration.Version, "Name", migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrations.GetStatus(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, appliedAt)
+		}
+		return nil
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	return nil
+}
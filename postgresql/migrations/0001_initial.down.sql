@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS verifications;
+DROP TABLE IF EXISTS reactions;
+DROP TABLE IF EXISTS links;
+DROP TABLE IF EXISTS casts;
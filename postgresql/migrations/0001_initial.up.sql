@@ -1,4 +1,6 @@
-CREATE TABLE casts (
+-- The tables written by the first versions of the plugin. IF NOT EXISTS lets the deployments that created them by
+-- hand adopt the migrations.
+CREATE TABLE IF NOT EXISTS casts (
   -- common to all messages 
   id SERIAL PRIMARY KEY,
   fid BIGINT NOT NULL,
@@ -26,9 +28,9 @@ CREATE TABLE casts (
   embeds JSONB,
   mentions BIGINT[],
   mentions_positions SMALLINT[]
-)
+);
 
-CREATE TABLE links (
+CREATE TABLE IF NOT EXISTS links (
   -- common to all messages 
   id SERIAL PRIMARY KEY,
 
@@ -47,9 +49,9 @@ CREATE TABLE links (
   target_fid BIGINT NOT NULL,
   hash TEXT,
   type TEXT
-)
+);
 
-CREATE TABLE reactions (
+CREATE TABLE IF NOT EXISTS reactions (
   -- common to all messages 
   id SERIAL PRIMARY KEY,
 
@@ -71,9 +73,9 @@ CREATE TABLE reactions (
   target_hash TEXT,
   target_fid BIGINT,
   target_url TEXT
-)
+);
 
-CREATE TABLE verifications (
+CREATE TABLE IF NOT EXISTS verifications (
   -- common to all messages 
   id SERIAL PRIMARY KEY,
 
@@ -92,4 +94,4 @@ CREATE TABLE verifications (
   verification_type SMALLINT, -- either 0 or 1
   chain_id SMALLINT,
   protocol SMALLINT
-)
\ No newline at end of file
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS username_proofs;
+DROP TABLE IF EXISTS user_data;
@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS user_data (
+  -- common to all messages 
+  id SERIAL PRIMARY KEY,
+
+  fid BIGINT NOT NULL,
+
+  -- TIME info
+  -- when the message was received by the hub
+  timestamp BIGINT NOT NULL,
+  -- when was the message created in the DB?
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  -- what was the last update in the DB?
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  hash TEXT,
+  -- pfp (1), display name (2), bio (3), url (5) or username (6): only the latest value of each type is kept
+  type SMALLINT NOT NULL,
+  value TEXT,
+
+  UNIQUE (fid, type)
+);
+
+CREATE TABLE IF NOT EXISTS username_proofs (
+  -- common to all messages 
+  id SERIAL PRIMARY KEY,
+
+  fid BIGINT NOT NULL,
+
+  -- TIME info
+  -- when the proof was made (unix timestamp, not farcaster time!)
+  timestamp BIGINT NOT NULL,
+  -- when was the message created in the DB?
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  -- what was the last update in the DB?
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  hash TEXT,
+  -- a name belongs to a single user, the latest proof wins
+  name TEXT NOT NULL UNIQUE,
+  owner TEXT,
+  signature TEXT,
+  -- fname (1) or ENS name (2)
+  type SMALLINT
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS verifications_fid_address;
+
+ALTER TABLE verifications
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS deleted_at;
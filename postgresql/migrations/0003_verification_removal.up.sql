@@ -0,0 +1,6 @@
+-- Verifications are soft-deleted like the other messages when they're removed
+ALTER TABLE verifications
+  ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP,
+  ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS verifications_fid_address ON verifications (fid, address);
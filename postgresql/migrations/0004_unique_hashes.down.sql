@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS reactions_fid_type_target;
+DROP INDEX IF EXISTS links_fid_type_target;
+
+ALTER TABLE verifications DROP CONSTRAINT IF EXISTS verifications_hash_key;
+ALTER TABLE reactions DROP CONSTRAINT IF EXISTS reactions_hash_key;
+ALTER TABLE links DROP CONSTRAINT IF EXISTS links_hash_key;
+ALTER TABLE casts DROP CONSTRAINT IF EXISTS casts_hash_key;
@@ -0,0 +1,14 @@
+-- Gossip redelivers messages: each message is written once, keyed by its hash. The copies written before are dropped.
+DELETE FROM casts a USING casts b WHERE a.hash = b.hash AND a.id > b.id;
+DELETE FROM links a USING links b WHERE a.hash = b.hash AND a.id > b.id;
+DELETE FROM reactions a USING reactions b WHERE a.hash = b.hash AND a.id > b.id;
+DELETE FROM verifications a USING verifications b WHERE a.hash = b.hash AND a.id > b.id;
+
+ALTER TABLE casts ADD CONSTRAINT casts_hash_key UNIQUE (hash);
+ALTER TABLE links ADD CONSTRAINT links_hash_key UNIQUE (hash);
+ALTER TABLE reactions ADD CONSTRAINT reactions_hash_key UNIQUE (hash);
+ALTER TABLE verifications ADD CONSTRAINT verifications_hash_key UNIQUE (hash);
+
+-- The messages of a user about the same target are compared to know which one wins
+CREATE INDEX IF NOT EXISTS links_fid_type_target ON links (fid, type, target_fid);
+CREATE INDEX IF NOT EXISTS reactions_fid_type_target ON reactions (fid, reaction_type, target_hash, target_url);
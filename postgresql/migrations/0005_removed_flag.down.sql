@@ -0,0 +1,3 @@
+ALTER TABLE links DROP COLUMN IF EXISTS removed;
+ALTER TABLE reactions DROP COLUMN IF EXISTS removed;
+ALTER TABLE verifications DROP COLUMN IF EXISTS removed;
@@ -0,0 +1,10 @@
+-- Removes are told apart from the adds that lost & were written as deleted: only a remove wins over an add with the
+-- same timestamp
+ALTER TABLE links ADD COLUMN IF NOT EXISTS removed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE reactions ADD COLUMN IF NOT EXISTS removed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE verifications ADD COLUMN IF NOT EXISTS removed BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The rows written before can't be told apart: the deleted ones are kept as removes
+UPDATE links SET removed = TRUE WHERE deleted_at IS NOT NULL;
+UPDATE reactions SET removed = TRUE WHERE deleted_at IS NOT NULL;
+UPDATE verifications SET removed = TRUE WHERE deleted_at IS NOT NULL;
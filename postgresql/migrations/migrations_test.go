@@ -0,0 +1,78 @@
+package migrations
+
+import (
+	"context"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// Are the embedded migrations complete & in order?
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "versions must follow each other")
+		assert.NotEmpty(t, migration.Up)
+		assert.NotEmpty(t, migration.Down)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	_, err := load(fstest.MapFS{"0001_casts.up.sql": {Data: []byte("SELECT 1")}})
+	assert.Error(t, err, "the down file is missing")
+
+	_, err = load(fstest.MapFS{"casts.sql": {Data: []byte("SELECT 1")}})
+	assert.Error(t, err, "the file name has no version")
+
+	_, err = load(fstest.MapFS{
+		"0001_casts.up.sql":   {Data: []byte("SELECT 1")},
+		"0001_casts.down.sql": {Data: []byte("SELECT 1")},
+		"0001_links.up.sql":   {Data: []byte("SELECT 1")},
+	})
+	assert.Error(t, err, "two migrations have the same version")
+}
+
+// Can the schema be created, reverted & created again? It needs a database that can be wiped, see FARSEER_PG_TEST_DB.
+func TestUpDown(t *testing.T) {
+	dbAddress := os.Getenv("FARSEER_PG_TEST_DB")
+	if dbAddress == "" {
+		t.Skip("FARSEER_PG_TEST_DB isn't set")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, dbAddress)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer conn.Close(ctx)
+
+	migrations, err := Load()
+	assert.NoError(t, err)
+
+	_, err = Up(ctx, conn)
+	assert.NoError(t, err)
+
+	applied, err := Up(ctx, conn)
+	assert.NoError(t, err)
+	assert.Empty(t, applied, "nothing to do the second time")
+
+	reverted, err := Down(ctx, conn, len(migrations))
+	assert.NoError(t, err)
+	assert.Len(t, reverted, len(migrations))
+
+	statuses, err := GetStatus(ctx, conn)
+	assert.NoError(t, err)
+	for _, status := range statuses {
+		assert.Nil(t, status.AppliedAt)
+	}
+
+	applied, err = Up(ctx, conn)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+}
@@ -3,13 +3,16 @@ package main
 import (
 	"context"
 	"errors"
+	"time"
 
 	handler "github.com/noctisatrae/farseer/handlers"
+	"github.com/noctisatrae/farseer/postgresql/migrations"
 	protos "github.com/noctisatrae/farseer/protos"
 	utils "github.com/noctisatrae/farseer/utils"
 
 	"github.com/charmbracelet/log"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mr-tron/base58"
 )
 
 const (
@@ -18,7 +21,8 @@ const (
 	SELECT created_at FROM casts WHERE hash = $1 
 	`
 
-	// A query that allows to add a cast into the DB
+	// A query that allows to add a cast into the DB. A redelivered cast isn't written twice, but a cast removed before
+	// it was seen fills the row kept for its removal: it stays removed, unless another user sent the removal.
 	CastAdd = `
 	INSERT INTO casts (
 		fid,
@@ -35,105 +39,245 @@ const (
 		mentions,
 		mentions_positions
 	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	ON CONFLICT (hash) DO UPDATE
+	SET
+		fid = EXCLUDED.fid,
+		timestamp = EXCLUDED.timestamp,
+		parent_hash = EXCLUDED.parent_hash,
+		parent_fid = EXCLUDED.parent_fid,
+		parent_url = EXCLUDED.parent_url,
+		text = EXCLUDED.text,
+		embeds = EXCLUDED.embeds,
+		mentions = EXCLUDED.mentions,
+		mentions_positions = EXCLUDED.mentions_positions,
+		deleted_at = CASE WHEN casts.fid = EXCLUDED.fid THEN casts.deleted_at END,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		casts.text IS NULL
 	`
-	CastAddRemoved = `
+
+	// A query that updates a cast when it's removed by its author, or keeps track of the removal if the cast wasn't
+	// seen yet.
+	// Every message is a single statement so it can be written in a batch.
+	CastRemove = `
 	INSERT INTO casts (
-		fid, 
+		fid,
 
 		timestamp,
 		deleted_at,
 
 		hash
 	) VALUES ($1, $2, CURRENT_TIMESTAMP, $3)
-	`
-
-	// A query that updates a cast when it's removed
-	UpdateCastOnRemove = `
-	UPDATE casts
+	ON CONFLICT (hash) DO UPDATE
 	SET
 		deleted_at = CURRENT_TIMESTAMP,
 		updated_at = CURRENT_TIMESTAMP
 	WHERE
-		hash = $1
+		casts.deleted_at IS NULL
+		AND casts.fid = EXCLUDED.fid
 	`
 
+	// Links, reactions & verifications follow the CRDT rules of Farcaster: for the same target, the message with the
+	// latest timestamp wins, then a removal wins over an add, then the highest hash wins. An add that loses is written
+	// as deleted & a removal is always kept as a deleted row flagged as removed, so an older add arriving late can't
+	// bring a link back.
+
 	// A query to add a link into the database
 	LinkAdd = `
+	WITH superseded AS (
+		UPDATE links
+		SET
+			deleted_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			fid = $2
+			AND type = $5
+			AND target_fid = $3
+			AND deleted_at IS NULL
+			AND (timestamp < $1 OR (timestamp = $1 AND hash < $4))
+	)
 	INSERT INTO links (
 		timestamp,
+		deleted_at,
 
 		fid,
 		target_fid,
 		hash,
 		type
-	) VALUES ($1, $2, $3, $4, $5)
+	)
+	SELECT $1::BIGINT, CASE WHEN EXISTS (
+		SELECT 1 FROM links
+		WHERE
+			fid = $2
+			AND type = $5
+			AND target_fid = $3
+			AND (timestamp > $1 OR (timestamp = $1 AND (removed OR hash > $4)))
+	) THEN CURRENT_TIMESTAMP END, $2::BIGINT, $3::BIGINT, $4::TEXT, $5::TEXT
+	ON CONFLICT (hash) DO NOTHING
 	`
 
-	LinkAddRemoved = `
+	LinkRemove = `
+	WITH removed AS (
+		UPDATE links
+		SET
+			deleted_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			fid = $2
+			AND type = $5
+			AND target_fid = $3
+			AND deleted_at IS NULL
+			AND timestamp <= $1
+	)
 	INSERT INTO links (
 		timestamp,
 		deleted_at,
+		removed,
 
 		fid,
 		target_fid,
 		hash,
 		type
-	) VALUES ($1, CURRENT_TIMESTAMP, $2, $3, $4, $5)
-	`
-
-	LinkRemove = `
-	UPDATE links
-	SET
-		deleted_at = CURRENT_TIMESTAMP,
-		updated_at = CURRENT_TIMESTAMP
-	WHERE
-		target_fid = $1
+	) VALUES ($1, CURRENT_TIMESTAMP, TRUE, $2, $3, $4, $5)
+	ON CONFLICT (hash) DO NOTHING
 	`
 
 	// Add a new reaction to the DB
 	ReactionAdd = `
+	WITH superseded AS (
+		UPDATE reactions
+		SET
+			deleted_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			fid = $1
+			AND reaction_type = $3
+			AND target_hash = $5
+			AND target_url = $7
+			AND deleted_at IS NULL
+			AND (timestamp < $2 OR (timestamp = $2 AND hash < $4))
+	)
 	INSERT INTO reactions (
 		fid,
 
 		timestamp,
-		
+		deleted_at,
+
 		reaction_type,
 		hash,
 		target_hash,
 		target_fid,
 		target_url
-	) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	)
+	SELECT $1::BIGINT, $2::BIGINT, CASE WHEN EXISTS (
+		SELECT 1 FROM reactions
+		WHERE
+			fid = $1
+			AND reaction_type = $3
+			AND target_hash = $5
+			AND target_url = $7
+			AND (timestamp > $2 OR (timestamp = $2 AND (removed OR hash > $4)))
+	) THEN CURRENT_TIMESTAMP END, $3::SMALLINT, $4::TEXT, $5::TEXT, $6::BIGINT, $7::TEXT
+	ON CONFLICT (hash) DO NOTHING
 	`
 
-	ReactionAddRemoved = `
+	ReactionRemove = `
+	WITH removed AS (
+		UPDATE reactions
+		SET
+			deleted_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			fid = $1
+			AND reaction_type = $3
+			AND target_hash = $5
+			AND target_url = $7
+			AND deleted_at IS NULL
+			AND timestamp <= $2
+	)
 	INSERT INTO reactions (
 		fid,
 
 		timestamp,
 		deleted_at,
-		
+		removed,
+
 		reaction_type,
 		hash,
 		target_hash,
 		target_fid,
 		target_url
-	) VALUES ($1, $2, CURRENT_TIMESTAMP, $3, $4, $5, $6, $7)
+	) VALUES ($1, $2, CURRENT_TIMESTAMP, TRUE, $3, $4, $5, $6, $7)
+	ON CONFLICT (hash) DO NOTHING
 	`
 
-	ReactionRemove = `
-	UPDATE reactions
+	// Only the latest value of each type of user data is kept: an older message doesn't override a newer one
+	UserDataAdd = `
+	INSERT INTO user_data (
+		fid,
+
+		timestamp,
+
+		hash,
+		type,
+		value
+	) VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (fid, type) DO UPDATE
 	SET
-		deleted_at = CURRENT_TIMESTAMP,
+		timestamp = EXCLUDED.timestamp,
+		hash = EXCLUDED.hash,
+		value = EXCLUDED.value,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		user_data.timestamp < EXCLUDED.timestamp
+		OR (user_data.timestamp = EXCLUDED.timestamp AND user_data.hash < EXCLUDED.hash)
+	`
+
+	// A name is owned by a single user: the latest proof replaces the previous one
+	UsernameProofAdd = `
+	INSERT INTO username_proofs (
+		fid,
+
+		timestamp,
+
+		hash,
+		name,
+		owner,
+		signature,
+		type
+	) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (name) DO UPDATE
+	SET
+		fid = EXCLUDED.fid,
+		timestamp = EXCLUDED.timestamp,
+		hash = EXCLUDED.hash,
+		owner = EXCLUDED.owner,
+		signature = EXCLUDED.signature,
+		type = EXCLUDED.type,
 		updated_at = CURRENT_TIMESTAMP
 	WHERE
-		target_hash = $1
+		username_proofs.timestamp < EXCLUDED.timestamp
+		OR (username_proofs.timestamp = EXCLUDED.timestamp AND username_proofs.hash < EXCLUDED.hash)
 	`
 
 	VerificationAdd = `
+	WITH superseded AS (
+		UPDATE verifications
+		SET
+			deleted_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			fid = $1
+			AND address = $4
+			AND protocol = $9
+			AND deleted_at IS NULL
+			AND (timestamp < $2 OR (timestamp = $2 AND hash < $3))
+	)
 	INSERT INTO verifications (
 		fid,
 
 		timestamp,
+		deleted_at,
 
 		hash,
 		address,
@@ -142,7 +286,44 @@ const (
 		verification_type,
 		chain_id,
 		protocol
-	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	)
+	SELECT $1::BIGINT, $2::BIGINT, CASE WHEN EXISTS (
+		SELECT 1 FROM verifications
+		WHERE
+			fid = $1
+			AND address = $4
+			AND protocol = $9
+			AND (timestamp > $2 OR (timestamp = $2 AND (removed OR hash > $3)))
+	) THEN CURRENT_TIMESTAMP END, $3::TEXT, $4::TEXT, $5::TEXT, $6::TEXT, $7::SMALLINT, $8::SMALLINT, $9::SMALLINT
+	ON CONFLICT (hash) DO NOTHING
+	`
+
+	// A verification is removed by its owner, for an address of a given protocol
+	VerificationRemove = `
+	WITH removed AS (
+		UPDATE verifications
+		SET
+			deleted_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			fid = $1
+			AND address = $4
+			AND protocol = $5
+			AND deleted_at IS NULL
+			AND timestamp <= $2
+	)
+	INSERT INTO verifications (
+		fid,
+
+		timestamp,
+		deleted_at,
+		removed,
+
+		hash,
+		address,
+		protocol
+	) VALUES ($1, $2, CURRENT_TIMESTAMP, TRUE, $3, $4, $5)
+	ON CONFLICT (hash) DO NOTHING
 	`
 )
 
@@ -156,68 +337,152 @@ const (
 // 	return timestamp, nil
 // }
 
-func CheckConfigParams(data *protos.MessageData, params map[string]interface{}, hash []byte, handlerFunc handler.HandlerBehaviour) error {
-	msgFilter := params["MessageTypesAllowed"]
-	fidFilter := params["FidsAllowed"]
+// The [handlers.postgresql] table of config.toml.
+//
+// ```toml
+// [handlers.postgresql]
+// DbAddress = "your-database-address" # required
+// SkipMigrations = false # optional: don't create & update the tables when the plugin starts
+// BatchSize = 500 # optional: how many messages are written at once
+// FlushInterval = 1000 # optional: how often the messages are written, in milliseconds
+// ```
+type Config struct {
+	DbAddress      string
+	SkipMigrations bool
+	BatchSize      uint
+	FlushInterval  uint
+}
+
+type PostgreSQL struct {
+	config Config
+	pool   *pgxpool.Pool
+	writer *batchWriter
+}
 
-	if msgFilter != nil && fidFilter != nil {
-		allowedMsgType := utils.IntersectionOfArrays(msgFilter.([]interface{}), []interface{}{utils.MsgTypeToInt(data.Type)})
-		allowedFids := utils.IntersectionOfArrays(fidFilter.([]interface{}), []interface{}{int64(data.Fid)})
-		if len(allowedMsgType) > 0 && len(allowedFids) > 0 {
-			return handlerFunc(data, hash, params)
-		}
+func (p *PostgreSQL) Name() string {
+	return "PostgreSQL"
+}
 
-		return nil
-	} else if msgFilter == nil && fidFilter != nil {
-		allowedFids := utils.IntersectionOfArrays(fidFilter.([]interface{}), []interface{}{int64(data.Fid)})
-		if len(allowedFids) > 0 {
-			return handlerFunc(data, hash, params)
-		}
-	} else if msgFilter != nil && fidFilter == nil {
-		allowedMsgType := utils.IntersectionOfArrays(msgFilter.([]interface{}), []interface{}{utils.MsgTypeToInt(data.Type)})
-		if len(allowedMsgType) > 0 {
-			return handlerFunc(data, hash, params)
-		}
-	} else {
-		return handlerFunc(data, hash, params)
+func (p *PostgreSQL) Configure(raw []byte) error {
+	var config Config
+	if err := handler.Decode(raw, &config); err != nil {
+		return err
+	}
+	if config.DbAddress == "" {
+		return errors.New("no DbAddress was provided, so no connection can be made to the DB")
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.FlushInterval == 0 {
+		config.FlushInterval = defaultFlushInterval
 	}
 
+	p.config = config
 	return nil
 }
 
-// InitBehaviour initializes the plugin by setting up a database connection.
-//
-// Example config.toml for this plugin:
-//
-// ```toml
-// [handlers.postgresql]
-// DbAddress = "your-database-address" # required
-// ```
-//
-// Parameters:
-// - params: A map containing configuration parameters.
-func InitBehaviour(params map[string]interface{}) error {
-	dbAddr := params["DbAddress"]
-	if dbAddr == nil {
-		return errors.New("no DbAddress was provided, so no connection can be made to the DB")
+// Init sets up the pool of connections to the database, brings the schema up to date & starts writing the messages.
+func (p *PostgreSQL) Init(ctx context.Context) error {
+	pool, err := pgxpool.New(ctx, p.config.DbAddress)
+	if err != nil {
+		return err
+	}
+	// the pool connects lazily, we want to know now if the DB can be reached
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return err
+	}
+
+	if !p.config.SkipMigrations {
+		if err := migrateSchema(ctx, pool); err != nil {
+			pool.Close()
+			return err
+		}
 	}
 
-	hdlCtx := context.Background()
-	conn, err := pgx.Connect(hdlCtx, dbAddr.(string))
+	p.pool = pool
+	p.writer = newBatchWriter(pool, int(p.config.BatchSize), time.Duration(p.config.FlushInterval)*time.Millisecond, *log.WithPrefix(p.Name()))
+	go p.writer.Run()
+
+	return nil
+}
+
+// The migrations hold a lock for the whole session, so they run on a connection of their own.
+func migrateSchema(ctx context.Context, pool *pgxpool.Pool) error {
+	conn, err := pool.Acquire(ctx)
 	if err != nil {
 		return err
 	}
+	defer conn.Release()
 
-	params["hdlCtx"] = hdlCtx
-	params["dbConn"] = conn
+	applied, err := migrations.Up(ctx, conn.Conn())
+	for _, migration := range applied {
+		log.Info("Applied migration! |", "Version", migration.Version, "Name", migration.Name)
+	}
+	return err
+}
+
+// Writes the messages that are still queued, then closes the connections.
+func (p *PostgreSQL) Close(ctx context.Context) error {
+	if p.pool == nil {
+		return nil
+	}
+	err := p.writer.Stop(ctx)
+	p.pool.Close()
+	p.pool = nil
+	return err
+}
+
+// Writes the queued messages now instead of waiting for the next batch.
+func (p *PostgreSQL) Flush(ctx context.Context) error {
+	return p.writer.Flush(ctx)
+}
+
+func (p *PostgreSQL) HandleMessage(ctx context.Context, m *protos.Message, meta handler.MessageMeta) error {
+	switch m.Data.Type {
+	case protos.MessageType_MESSAGE_TYPE_CAST_ADD:
+		return p.CastAddHandler(ctx, m.Data, m.Hash)
+	case protos.MessageType_MESSAGE_TYPE_CAST_REMOVE:
+		return p.CastRemoveHandler(ctx, m.Data, m.Hash)
+	case protos.MessageType_MESSAGE_TYPE_LINK_ADD:
+		return p.LinkAddHandler(ctx, m.Data, m.Hash)
+	case protos.MessageType_MESSAGE_TYPE_LINK_REMOVE:
+		return p.LinkRemoveHandler(ctx, m.Data, m.Hash)
+	case protos.MessageType_MESSAGE_TYPE_REACTION_ADD:
+		return p.ReactionAddHandler(ctx, m.Data, m.Hash)
+	case protos.MessageType_MESSAGE_TYPE_REACTION_REMOVE:
+		return p.ReactionRemoveHandler(ctx, m.Data, m.Hash)
+	case protos.MessageType_MESSAGE_TYPE_VERIFICATION_ADD_ETH_ADDRESS:
+		return p.VerificationAddHandler(ctx, m.Data, m.Hash)
+	case protos.MessageType_MESSAGE_TYPE_VERIFICATION_REMOVE:
+		return p.VerificationRemoveHandler(ctx, m.Data, m.Hash)
+	case protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD:
+		return p.UserDataAddHandler(ctx, m.Data, m.Hash)
+	case protos.MessageType_MESSAGE_TYPE_USERNAME_PROOF:
+		return p.UsernameProofHandler(ctx, m.Data, m.Hash)
+	}
 
 	return nil
 }
 
-func CastAddHandler(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
-	hdlCtx := params["hdlCtx"].(context.Context)
-	conn := params["dbConn"].(*pgx.Conn)
+// Only the messages saved in the DB are sent by the relay.
+func (p *PostgreSQL) MessageTypes() []protos.MessageType {
+	return []protos.MessageType{
+		protos.MessageType_MESSAGE_TYPE_CAST_ADD,
+		protos.MessageType_MESSAGE_TYPE_CAST_REMOVE,
+		protos.MessageType_MESSAGE_TYPE_LINK_ADD,
+		protos.MessageType_MESSAGE_TYPE_LINK_REMOVE,
+		protos.MessageType_MESSAGE_TYPE_REACTION_ADD,
+		protos.MessageType_MESSAGE_TYPE_REACTION_REMOVE,
+		protos.MessageType_MESSAGE_TYPE_VERIFICATION_ADD_ETH_ADDRESS,
+		protos.MessageType_MESSAGE_TYPE_VERIFICATION_REMOVE,
+		protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD,
+		protos.MessageType_MESSAGE_TYPE_USERNAME_PROOF,
+	}
+}
 
+func (p *PostgreSQL) CastAddHandler(ctx context.Context, data *protos.MessageData, hash []byte) error {
 	hashStr := utils.BytesToHex(hash)
 
 	castAddBody := data.GetCastAddBody()
@@ -232,7 +497,7 @@ func CastAddHandler(data *protos.MessageData, hash []byte, params map[string]int
 		parentUrl = castAddBody.GetParentUrl()
 	}
 
-	_, err := conn.Exec(hdlCtx, CastAdd,
+	return p.writer.Enqueue(CastAdd,
 		data.Fid,
 		data.Timestamp,
 
@@ -246,43 +511,25 @@ func CastAddHandler(data *protos.MessageData, hash []byte, params map[string]int
 		castAddBody.Mentions,
 		castAddBody.MentionsPositions,
 	)
-
-	return err
 }
 
-func CastRemoveHandler(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
-	hdlCtx := params["hdlCtx"].(context.Context)
-	conn := params["dbConn"].(*pgx.Conn)
-
+func (p *PostgreSQL) CastRemoveHandler(ctx context.Context, data *protos.MessageData, hash []byte) error {
 	castHashToRemove := utils.BytesToHex(data.GetCastRemoveBody().TargetHash)
 
-	cmdTag, err := conn.Exec(hdlCtx, UpdateCastOnRemove, castHashToRemove)
-	if err != nil {
-		return err
-	} else if cmdTag.RowsAffected() == 0 {
-		_, err = conn.Exec(
-			hdlCtx,
-			CastAddRemoved,
-			data.Fid,
-
-			data.Timestamp, // timestamp
+	return p.writer.Enqueue(CastRemove,
+		data.Fid,
 
-			castHashToRemove,
-		)
-		return err
-	}
+		data.Timestamp, // timestamp
 
-	return err
+		castHashToRemove,
+	)
 }
 
-func LinkAddHandler(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
-	hdlCtx := params["hdlCtx"].(context.Context)
-	conn := params["dbConn"].(*pgx.Conn)
-
+func (p *PostgreSQL) LinkAddHandler(ctx context.Context, data *protos.MessageData, hash []byte) error {
 	LinkHash := utils.BytesToHex(hash)
 
 	LinkAddBody := data.GetLinkBody()
-	_, err := conn.Exec(hdlCtx, LinkAdd,
+	return p.writer.Enqueue(LinkAdd,
 		data.Timestamp,
 
 		data.Fid,
@@ -290,102 +537,117 @@ func LinkAddHandler(data *protos.MessageData, hash []byte, params map[string]int
 		LinkHash,
 		LinkAddBody.Type,
 	)
-
-	return err
 }
 
-func LinkRemoveHandler(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
-	hdlCtx := params["hdlCtx"].(context.Context)
-	conn := params["dbConn"].(*pgx.Conn)
-
+func (p *PostgreSQL) LinkRemoveHandler(ctx context.Context, data *protos.MessageData, hash []byte) error {
 	LinkRemoveBody := data.GetLinkBody()
 	LinkHash := utils.BytesToHex(hash)
 
-	cmdTag, err := conn.Exec(hdlCtx, LinkRemove, LinkRemoveBody.GetTargetFid())
-	if err != nil {
-		return err
-	} else if cmdTag.RowsAffected() == 0 {
-		_, err = conn.Exec(hdlCtx, LinkAddRemoved,
-			data.Timestamp,
-			data.Fid,
-			LinkRemoveBody.GetTargetFid(),
-			LinkHash,
-			LinkRemoveBody.Type,
-		)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return p.writer.Enqueue(LinkRemove,
+		data.Timestamp,
+		data.Fid,
+		LinkRemoveBody.GetTargetFid(),
+		LinkHash,
+		LinkRemoveBody.Type,
+	)
 }
 
-func ReactionAddHandler(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
-	hdlCtx := params["hdlCtx"].(context.Context)
-	conn := params["dbConn"].(*pgx.Conn)
-
+func (p *PostgreSQL) ReactionAddHandler(ctx context.Context, data *protos.MessageData, hash []byte) error {
 	ReactionAddBody := data.GetReactionBody()
-	_, err := conn.Exec(hdlCtx, ReactionAdd,
+	return p.writer.Enqueue(ReactionAdd,
 		data.Fid,
 		data.Timestamp,
 		ReactionAddBody.Type,
 		utils.BytesToHex(hash),
-		utils.BytesToHex(ReactionAddBody.GetTargetCastId().Hash),
+		utils.BytesToHex(ReactionAddBody.GetTargetCastId().GetHash()),
 		ReactionAddBody.GetTargetCastId().GetFid(),
 		ReactionAddBody.GetTargetUrl(),
 	)
-
-	return err
 }
 
-func ReactionRemoveHandler(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
-	hdlCtx := params["hdlCtx"].(context.Context)
-	conn := params["dbConn"].(*pgx.Conn)
-
+func (p *PostgreSQL) ReactionRemoveHandler(ctx context.Context, data *protos.MessageData, hash []byte) error {
 	ReactionRemoveBody := data.GetReactionBody()
-	cmdTag, err := conn.Exec(hdlCtx, ReactionRemove,
+	return p.writer.Enqueue(ReactionRemove,
+		data.Fid,
+		data.Timestamp,
+		ReactionRemoveBody.Type,
+		utils.BytesToHex(hash),
 		utils.BytesToHex(ReactionRemoveBody.GetTargetCastId().GetHash()),
+		ReactionRemoveBody.GetTargetCastId().GetFid(),
+		ReactionRemoveBody.GetTargetUrl(),
 	)
-	if err != nil {
-		return err
-	} else if cmdTag.RowsAffected() == 0 {
-		_, err := conn.Exec(hdlCtx, ReactionAddRemoved,
-			data.Fid,
-			data.Timestamp,
-			ReactionRemoveBody.Type,
-			utils.BytesToHex(hash),
-			utils.BytesToHex(ReactionRemoveBody.GetTargetCastId().GetHash()),
-			ReactionRemoveBody.GetTargetCastId().Fid,
-			ReactionRemoveBody.GetTargetUrl(),
-		)
-		if err != nil {
-			return err
+}
+
+// Addresses, signatures & block hashes are written the way each chain does: hex for Ethereum, base58 for Solana.
+func encodeForProtocol(protocol protos.Protocol, bytes []byte) string {
+	if protocol == protos.Protocol_PROTOCOL_SOLANA {
+		if bytes == nil {
+			return ""
 		}
+		return base58.Encode(bytes)
 	}
+	return utils.BytesToHex(bytes)
+}
 
-	return nil
+func (p *PostgreSQL) VerificationAddHandler(ctx context.Context, data *protos.MessageData, hash []byte) error {
+	verificationBody := data.GetVerificationAddAddressBody()
+	protocol := verificationBody.Protocol
+
+	return p.writer.Enqueue(VerificationAdd,
+		data.Fid,
+		data.Timestamp,
+
+		utils.BytesToHex(hash),
+		encodeForProtocol(protocol, verificationBody.Address),
+		encodeForProtocol(protocol, verificationBody.ClaimSignature),
+		encodeForProtocol(protocol, verificationBody.BlockHash),
+		verificationBody.VerificationType,
+		verificationBody.ChainId,
+		int32(protocol),
+	)
 }
 
-// Exported variable
-var PluginHandler = handler.Handler{
-	Name:        "PostgreSQL",
-	InitHandler: InitBehaviour,
-	CastAddHandler: func(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
-		return CheckConfigParams(data, params, hash, CastAddHandler)
-	},
-	CastRemoveHandler: func(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
-		return CheckConfigParams(data, params, hash, CastRemoveHandler)
-	},
-	LinkAddHandler: func(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
-		return CheckConfigParams(data, params, hash, LinkAddHandler)
-	},
-	LinkRemoveHandler: func(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
-		return CheckConfigParams(data, params, hash, LinkRemoveHandler)
-	},
-	ReactionAddHandler: func(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
-		return CheckConfigParams(data, params, hash, ReactionAddHandler)
-	},
-	ReactionRemoveHandler: func(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
-		return CheckConfigParams(data, params, hash, ReactionRemoveHandler)
-	},
+func (p *PostgreSQL) VerificationRemoveHandler(ctx context.Context, data *protos.MessageData, hash []byte) error {
+	verificationBody := data.GetVerificationRemoveBody()
+	protocol := verificationBody.Protocol
+	address := encodeForProtocol(protocol, verificationBody.Address)
+
+	// the removal is kept even if the verification wasn't seen yet, so it stays removed when it arrives
+	return p.writer.Enqueue(VerificationRemove,
+		data.Fid,
+		data.Timestamp,
+
+		utils.BytesToHex(hash),
+		address,
+		int32(protocol),
+	)
 }
+
+func (p *PostgreSQL) UserDataAddHandler(ctx context.Context, data *protos.MessageData, hash []byte) error {
+	userDataBody := data.GetUserDataBody()
+	return p.writer.Enqueue(UserDataAdd,
+		data.Fid,
+		data.Timestamp,
+
+		utils.BytesToHex(hash),
+		userDataBody.Type,
+		userDataBody.Value,
+	)
+}
+
+func (p *PostgreSQL) UsernameProofHandler(ctx context.Context, data *protos.MessageData, hash []byte) error {
+	proof := data.GetUsernameProofBody()
+	return p.writer.Enqueue(UsernameProofAdd,
+		proof.Fid,
+		proof.Timestamp,
+
+		utils.BytesToHex(hash),
+		string(proof.Name),
+		utils.BytesToHex(proof.Owner),
+		utils.BytesToHex(proof.Signature),
+		proof.Type,
+	)
+}
+
+// Exported variable
+var Plugin handler.Plugin = &PostgreSQL{}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	handler "github.com/noctisatrae/farseer/handlers"
+
+	"github.com/charmbracelet/log"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultBatchSize     = 500
+	defaultFlushInterval = 1000 // ms
+	// How many batches can wait in the queue while the DB is unreachable, before the plugin says it's busy.
+	maxQueuedBatches = 100
+
+	minFlushBackoff = 500 * time.Millisecond
+	maxFlushBackoff = 30 * time.Second
+)
+
+// A statement waiting to be written, with its arguments.
+type statement struct {
+	sql  string
+	args []any
+}
+
+// Writes the statements queued by the handlers in batches: the queue is flushed in a single transaction when it holds
+// batchSize statements or every interval. When the DB is unreachable (e.g. Postgres is restarting) the statements
+// stay queued & the flush is retried with a backoff, so no message is lost.
+type batchWriter struct {
+	batchSize int
+	maxQueued int
+	interval  time.Duration
+	ll        log.Logger
+	// Writes the statements in order, returning how many were written. Replaced in the tests.
+	write func(ctx context.Context, statements []statement) (int, error)
+
+	mu    sync.Mutex
+	queue []statement
+
+	// only one flush at a time, so the statements are written in order
+	flushMu sync.Mutex
+
+	flushCh chan struct{}
+	stopCh  chan struct{}
+	done    chan struct{}
+}
+
+func newBatchWriter(pool *pgxpool.Pool, batchSize int, interval time.Duration, ll log.Logger) *batchWriter {
+	w := &batchWriter{
+		batchSize: batchSize,
+		maxQueued: batchSize * maxQueuedBatches,
+		interval:  interval,
+		ll:        ll,
+		flushCh:   make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	w.write = func(ctx context.Context, statements []statement) (int, error) {
+		return writeStatements(ctx, pool, statements, ll)
+	}
+
+	return w
+}
+
+// Queues a statement without waiting for it to be written. When the queue is full, handlers.ErrBusy is returned so
+// the relay sends the message again later: it slows down instead of the plugin dropping messages.
+func (w *batchWriter) Enqueue(sql string, args ...any) error {
+	w.mu.Lock()
+	if len(w.queue) >= w.maxQueued {
+		queued := len(w.queue)
+		w.mu.Unlock()
+		return fmt.Errorf("%w: %d messages are waiting for the DB", handler.ErrBusy, queued)
+	}
+	w.queue = append(w.queue, statement{sql: sql, args: args})
+	full := len(w.queue) >= w.batchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Number of statements waiting to be written.
+func (w *batchWriter) Queued() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.queue)
+}
+
+// Writes every queued statement. The ones that couldn't be written stay queued.
+func (w *batchWriter) Flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	statements := w.queue
+	w.mu.Unlock()
+
+	for len(statements) > 0 {
+		chunk := statements[:min(len(statements), w.batchSize)]
+		written, err := w.write(ctx, chunk)
+		w.dequeue(written)
+		if err != nil {
+			return err
+		}
+		statements = statements[written:]
+	}
+
+	return nil
+}
+
+// Removes the statements written from the front of the queue.
+func (w *batchWriter) dequeue(written int) {
+	if written == 0 {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.queue = w.queue[written:]
+	if len(w.queue) == 0 {
+		w.queue = nil
+	}
+}
+
+// Flushes the queue on the size & time thresholds until Stop is called.
+func (w *batchWriter) Run() {
+	defer close(w.done)
+
+	timer := time.NewTimer(w.interval)
+	defer timer.Stop()
+	var backoff time.Duration
+
+	for {
+		// while backing off, a full queue doesn't trigger a flush: we wait for the DB to come back
+		flushCh := w.flushCh
+		if backoff > 0 {
+			flushCh = nil
+		}
+
+		select {
+		case <-w.stopCh:
+			return
+		case <-timer.C:
+		case <-flushCh:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		}
+
+		if err := w.Flush(context.Background()); err != nil {
+			backoff = min(max(backoff*2, minFlushBackoff), maxFlushBackoff)
+			w.ll.Error("Couldn't write to the DB, retrying! |", "Queued", w.Queued(), "RetryIn", backoff, "Error", err)
+			timer.Reset(backoff)
+			continue
+		}
+
+		if backoff > 0 {
+			w.ll.Info("The DB is reachable again!")
+		}
+		backoff = 0
+		timer.Reset(w.interval)
+	}
+}
+
+// Stops flushing in the background, then writes what's left in the queue.
+func (w *batchWriter) Stop(ctx context.Context) error {
+	close(w.stopCh)
+	<-w.done
+
+	if err := w.Flush(ctx); err != nil {
+		w.ll.Error("Couldn't write the queued messages, they're lost! |", "Queued", w.Queued(), "Error", err)
+		return err
+	}
+	return nil
+}
+
+// Writes the statements in a single transaction. If the DB refuses one of them, they're written one by one so a bad
+// message doesn't block the queue forever.
+func writeStatements(ctx context.Context, pool *pgxpool.Pool, statements []statement, ll log.Logger) (int, error) {
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		batch := &pgx.Batch{}
+		for _, s := range statements {
+			batch.Queue(s.sql, s.args...)
+		}
+		return tx.SendBatch(ctx, batch).Close()
+	})
+	if err == nil {
+		return len(statements), nil
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return 0, err
+	}
+
+	for i, s := range statements {
+		_, err := pool.Exec(ctx, s.sql, s.args...)
+		if errors.As(err, &pgErr) {
+			ll.Error("The DB refused a message, it's dropped! |", "Error", err)
+		} else if err != nil {
+			return i, err
+		}
+	}
+
+	return len(statements), nil
+}
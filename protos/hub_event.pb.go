@@ -0,0 +1,320 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        v5.27.1
+// source: hub_event.proto
+
+package __
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type HubEventType int32
+
+const (
+	HubEventType_HUB_EVENT_TYPE_NONE          HubEventType = 0
+	HubEventType_HUB_EVENT_TYPE_MERGE_MESSAGE HubEventType = 1
+)
+
+// Enum value maps for HubEventType.
+var (
+	HubEventType_name = map[int32]string{
+		0: "HUB_EVENT_TYPE_NONE",
+		1: "HUB_EVENT_TYPE_MERGE_MESSAGE",
+	}
+	HubEventType_value = map[string]int32{
+		"HUB_EVENT_TYPE_NONE":          0,
+		"HUB_EVENT_TYPE_MERGE_MESSAGE": 1,
+	}
+)
+
+func (x HubEventType) Enum() *HubEventType {
+	p := new(HubEventType)
+	*p = x
+	return p
+}
+
+func (x HubEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HubEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_hub_event_proto_enumTypes[0].Descriptor()
+}
+
+func (HubEventType) Type() protoreflect.EnumType {
+	return &file_hub_event_proto_enumTypes[0]
+}
+
+func (x HubEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HubEventType.Descriptor instead.
+func (HubEventType) EnumDescriptor() ([]byte, []int) {
+	return file_hub_event_proto_rawDescGZIP(), []int{0}
+}
+
+type MergeMessageBody struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Message         *Message   `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	DeletedMessages []*Message `protobuf:"bytes,2,rep,name=deleted_messages,json=deletedMessages,proto3" json:"deleted_messages,omitempty"`
+}
+
+func (x *MergeMessageBody) Reset() {
+	*x = MergeMessageBody{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_event_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MergeMessageBody) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MergeMessageBody) ProtoMessage() {}
+
+func (x *MergeMessageBody) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_event_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MergeMessageBody.ProtoReflect.Descriptor instead.
+func (*MergeMessageBody) Descriptor() ([]byte, []int) {
+	return file_hub_event_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MergeMessageBody) GetMessage() *Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *MergeMessageBody) GetDeletedMessages() []*Message {
+	if x != nil {
+		return x.DeletedMessages
+	}
+	return nil
+}
+
+type HubEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type HubEventType `protobuf:"varint,1,opt,name=type,proto3,enum=HubEventType" json:"type,omitempty"`
+	Id   uint64       `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	// Types that are assignable to Body:
+	//	*HubEvent_MergeMessageBody
+	Body isHubEvent_Body `protobuf_oneof:"body"`
+}
+
+func (x *HubEvent) Reset() {
+	*x = HubEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hub_event_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HubEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HubEvent) ProtoMessage() {}
+
+func (x *HubEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_hub_event_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HubEvent.ProtoReflect.Descriptor instead.
+func (*HubEvent) Descriptor() ([]byte, []int) {
+	return file_hub_event_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HubEvent) GetType() HubEventType {
+	if x != nil {
+		return x.Type
+	}
+	return HubEventType_HUB_EVENT_TYPE_NONE
+}
+
+func (x *HubEvent) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (m *HubEvent) GetBody() isHubEvent_Body {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
+func (x *HubEvent) GetMergeMessageBody() *MergeMessageBody {
+	if x, ok := x.GetBody().(*HubEvent_MergeMessageBody); ok {
+		return x.MergeMessageBody
+	}
+	return nil
+}
+
+type isHubEvent_Body interface {
+	isHubEvent_Body()
+}
+
+type HubEvent_MergeMessageBody struct {
+	MergeMessageBody *MergeMessageBody `protobuf:"bytes,3,opt,name=merge_message_body,json=mergeMessageBody,proto3,oneof"`
+}
+
+func (*HubEvent_MergeMessageBody) isHubEvent_Body() {}
+
+var File_hub_event_proto protoreflect.FileDescriptor
+
+var file_hub_event_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x68, 0x75, 0x62, 0x5f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x1a, 0x0d, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x22, 0x6b, 0x0a, 0x10, 0x4d, 0x65, 0x72, 0x67, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x42, 0x6f, 0x64, 0x79, 0x12, 0x22, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x33, 0x0a, 0x10, 0x64, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x64, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x0f, 0x64, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x22, 0x88, 0x01,
+	0x0a, 0x08, 0x48, 0x75, 0x62, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x48, 0x75, 0x62, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x0e, 0x0a,
+	0x02, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x41, 0x0a,
+	0x12, 0x6d, 0x65, 0x72, 0x67, 0x65, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x62,
+	0x6f, 0x64, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x4d, 0x65, 0x72, 0x67,
+	0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x42, 0x6f, 0x64, 0x79, 0x48, 0x00, 0x52, 0x10,
+	0x6d, 0x65, 0x72, 0x67, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x42, 0x6f, 0x64, 0x79,
+	0x42, 0x06, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x2a, 0x49, 0x0a, 0x0c, 0x48, 0x75, 0x62, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x17, 0x0a, 0x13, 0x48, 0x55, 0x42, 0x5f,
+	0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4e, 0x4f, 0x4e, 0x45, 0x10,
+	0x00, 0x12, 0x20, 0x0a, 0x1c, 0x48, 0x55, 0x42, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x54,
+	0x59, 0x50, 0x45, 0x5f, 0x4d, 0x45, 0x52, 0x47, 0x45, 0x5f, 0x4d, 0x45, 0x53, 0x53, 0x41, 0x47,
+	0x45, 0x10, 0x01, 0x42, 0x03, 0x5a, 0x01, 0x2e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_hub_event_proto_rawDescOnce sync.Once
+	file_hub_event_proto_rawDescData = file_hub_event_proto_rawDesc
+)
+
+func file_hub_event_proto_rawDescGZIP() []byte {
+	file_hub_event_proto_rawDescOnce.Do(func() {
+		file_hub_event_proto_rawDescData = protoimpl.X.CompressGZIP(file_hub_event_proto_rawDescData)
+	})
+	return file_hub_event_proto_rawDescData
+}
+
+var file_hub_event_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_hub_event_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_hub_event_proto_goTypes = []interface{}{
+	(HubEventType)(0),        // 0: HubEventType
+	(*MergeMessageBody)(nil), // 1: MergeMessageBody
+	(*HubEvent)(nil),         // 2: HubEvent
+	(*Message)(nil),          // 3: Message
+}
+var file_hub_event_proto_depIdxs = []int32{
+	3, // 0: MergeMessageBody.message:type_name -> Message
+	3, // 1: MergeMessageBody.deleted_messages:type_name -> Message
+	0, // 2: HubEvent.type:type_name -> HubEventType
+	1, // 3: HubEvent.merge_message_body:type_name -> MergeMessageBody
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_hub_event_proto_init() }
+func file_hub_event_proto_init() {
+	if File_hub_event_proto != nil {
+		return
+	}
+	file_message_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_hub_event_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MergeMessageBody); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hub_event_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HubEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_hub_event_proto_msgTypes[1].OneofWrappers = []interface{}{
+		(*HubEvent_MergeMessageBody)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_hub_event_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_hub_event_proto_goTypes,
+		DependencyIndexes: file_hub_event_proto_depIdxs,
+		EnumInfos:         file_hub_event_proto_enumTypes,
+		MessageInfos:      file_hub_event_proto_msgTypes,
+	}.Build()
+	File_hub_event_proto = out.File
+	file_hub_event_proto_rawDesc = nil
+	file_hub_event_proto_goTypes = nil
+	file_hub_event_proto_depIdxs = nil
+}
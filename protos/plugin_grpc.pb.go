@@ -0,0 +1,537 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v5.27.1
+// source: plugin.proto
+
+package __
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// HandlerPluginClient is the client API for HandlerPlugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type HandlerPluginClient interface {
+	Init(ctx context.Context, in *PluginInitRequest, opts ...grpc.CallOption) (*PluginInitResponse, error)
+	CastAdd(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error)
+	CastRemove(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error)
+	FrameAction(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error)
+	ReactionAdd(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error)
+	ReactionRemove(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error)
+	LinkAdd(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error)
+	LinkRemove(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error)
+	VerificationAdd(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error)
+	VerificationRemove(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error)
+	UserDataAdd(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error)
+	UsernameProof(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error)
+	LinkCompactState(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error)
+}
+
+type handlerPluginClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHandlerPluginClient(cc grpc.ClientConnInterface) HandlerPluginClient {
+	return &handlerPluginClient{cc}
+}
+
+func (c *handlerPluginClient) Init(ctx context.Context, in *PluginInitRequest, opts ...grpc.CallOption) (*PluginInitResponse, error) {
+	out := new(PluginInitResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/Init", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerPluginClient) CastAdd(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error) {
+	out := new(PluginResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/CastAdd", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerPluginClient) CastRemove(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error) {
+	out := new(PluginResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/CastRemove", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerPluginClient) FrameAction(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error) {
+	out := new(PluginResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/FrameAction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerPluginClient) ReactionAdd(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error) {
+	out := new(PluginResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/ReactionAdd", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerPluginClient) ReactionRemove(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error) {
+	out := new(PluginResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/ReactionRemove", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerPluginClient) LinkAdd(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error) {
+	out := new(PluginResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/LinkAdd", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerPluginClient) LinkRemove(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error) {
+	out := new(PluginResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/LinkRemove", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerPluginClient) VerificationAdd(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error) {
+	out := new(PluginResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/VerificationAdd", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerPluginClient) VerificationRemove(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error) {
+	out := new(PluginResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/VerificationRemove", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerPluginClient) UserDataAdd(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error) {
+	out := new(PluginResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/UserDataAdd", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerPluginClient) UsernameProof(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error) {
+	out := new(PluginResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/UsernameProof", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *handlerPluginClient) LinkCompactState(ctx context.Context, in *PluginMessage, opts ...grpc.CallOption) (*PluginResponse, error) {
+	out := new(PluginResponse)
+	err := c.cc.Invoke(ctx, "/HandlerPlugin/LinkCompactState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HandlerPluginServer is the server API for HandlerPlugin service.
+// All implementations must embed UnimplementedHandlerPluginServer
+// for forward compatibility
+type HandlerPluginServer interface {
+	Init(context.Context, *PluginInitRequest) (*PluginInitResponse, error)
+	CastAdd(context.Context, *PluginMessage) (*PluginResponse, error)
+	CastRemove(context.Context, *PluginMessage) (*PluginResponse, error)
+	FrameAction(context.Context, *PluginMessage) (*PluginResponse, error)
+	ReactionAdd(context.Context, *PluginMessage) (*PluginResponse, error)
+	ReactionRemove(context.Context, *PluginMessage) (*PluginResponse, error)
+	LinkAdd(context.Context, *PluginMessage) (*PluginResponse, error)
+	LinkRemove(context.Context, *PluginMessage) (*PluginResponse, error)
+	VerificationAdd(context.Context, *PluginMessage) (*PluginResponse, error)
+	VerificationRemove(context.Context, *PluginMessage) (*PluginResponse, error)
+	UserDataAdd(context.Context, *PluginMessage) (*PluginResponse, error)
+	UsernameProof(context.Context, *PluginMessage) (*PluginResponse, error)
+	LinkCompactState(context.Context, *PluginMessage) (*PluginResponse, error)
+	mustEmbedUnimplementedHandlerPluginServer()
+}
+
+// UnimplementedHandlerPluginServer must be embedded to have forward compatible implementations.
+type UnimplementedHandlerPluginServer struct {
+}
+
+func (UnimplementedHandlerPluginServer) Init(context.Context, *PluginInitRequest) (*PluginInitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Init not implemented")
+}
+func (UnimplementedHandlerPluginServer) CastAdd(context.Context, *PluginMessage) (*PluginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CastAdd not implemented")
+}
+func (UnimplementedHandlerPluginServer) CastRemove(context.Context, *PluginMessage) (*PluginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CastRemove not implemented")
+}
+func (UnimplementedHandlerPluginServer) FrameAction(context.Context, *PluginMessage) (*PluginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FrameAction not implemented")
+}
+func (UnimplementedHandlerPluginServer) ReactionAdd(context.Context, *PluginMessage) (*PluginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReactionAdd not implemented")
+}
+func (UnimplementedHandlerPluginServer) ReactionRemove(context.Context, *PluginMessage) (*PluginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReactionRemove not implemented")
+}
+func (UnimplementedHandlerPluginServer) LinkAdd(context.Context, *PluginMessage) (*PluginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LinkAdd not implemented")
+}
+func (UnimplementedHandlerPluginServer) LinkRemove(context.Context, *PluginMessage) (*PluginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LinkRemove not implemented")
+}
+func (UnimplementedHandlerPluginServer) VerificationAdd(context.Context, *PluginMessage) (*PluginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerificationAdd not implemented")
+}
+func (UnimplementedHandlerPluginServer) VerificationRemove(context.Context, *PluginMessage) (*PluginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerificationRemove not implemented")
+}
+func (UnimplementedHandlerPluginServer) UserDataAdd(context.Context, *PluginMessage) (*PluginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UserDataAdd not implemented")
+}
+func (UnimplementedHandlerPluginServer) UsernameProof(context.Context, *PluginMessage) (*PluginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UsernameProof not implemented")
+}
+func (UnimplementedHandlerPluginServer) LinkCompactState(context.Context, *PluginMessage) (*PluginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LinkCompactState not implemented")
+}
+func (UnimplementedHandlerPluginServer) mustEmbedUnimplementedHandlerPluginServer() {}
+
+// UnsafeHandlerPluginServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HandlerPluginServer will
+// result in compilation errors.
+type UnsafeHandlerPluginServer interface {
+	mustEmbedUnimplementedHandlerPluginServer()
+}
+
+func RegisterHandlerPluginServer(s grpc.ServiceRegistrar, srv HandlerPluginServer) {
+	s.RegisterService(&HandlerPlugin_ServiceDesc, srv)
+}
+
+func _HandlerPlugin_Init_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginInitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).Init(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/Init",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).Init(ctx, req.(*PluginInitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HandlerPlugin_CastAdd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).CastAdd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/CastAdd",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).CastAdd(ctx, req.(*PluginMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HandlerPlugin_CastRemove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).CastRemove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/CastRemove",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).CastRemove(ctx, req.(*PluginMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HandlerPlugin_FrameAction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).FrameAction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/FrameAction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).FrameAction(ctx, req.(*PluginMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HandlerPlugin_ReactionAdd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).ReactionAdd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/ReactionAdd",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).ReactionAdd(ctx, req.(*PluginMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HandlerPlugin_ReactionRemove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).ReactionRemove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/ReactionRemove",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).ReactionRemove(ctx, req.(*PluginMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HandlerPlugin_LinkAdd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).LinkAdd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/LinkAdd",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).LinkAdd(ctx, req.(*PluginMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HandlerPlugin_LinkRemove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).LinkRemove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/LinkRemove",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).LinkRemove(ctx, req.(*PluginMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HandlerPlugin_VerificationAdd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).VerificationAdd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/VerificationAdd",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).VerificationAdd(ctx, req.(*PluginMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HandlerPlugin_VerificationRemove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).VerificationRemove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/VerificationRemove",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).VerificationRemove(ctx, req.(*PluginMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HandlerPlugin_UserDataAdd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).UserDataAdd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/UserDataAdd",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).UserDataAdd(ctx, req.(*PluginMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HandlerPlugin_UsernameProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).UsernameProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/UsernameProof",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).UsernameProof(ctx, req.(*PluginMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HandlerPlugin_LinkCompactState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HandlerPluginServer).LinkCompactState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HandlerPlugin/LinkCompactState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HandlerPluginServer).LinkCompactState(ctx, req.(*PluginMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// HandlerPlugin_ServiceDesc is the grpc.ServiceDesc for HandlerPlugin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var HandlerPlugin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "HandlerPlugin",
+	HandlerType: (*HandlerPluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Init",
+			Handler:    _HandlerPlugin_Init_Handler,
+		},
+		{
+			MethodName: "CastAdd",
+			Handler:    _HandlerPlugin_CastAdd_Handler,
+		},
+		{
+			MethodName: "CastRemove",
+			Handler:    _HandlerPlugin_CastRemove_Handler,
+		},
+		{
+			MethodName: "FrameAction",
+			Handler:    _HandlerPlugin_FrameAction_Handler,
+		},
+		{
+			MethodName: "ReactionAdd",
+			Handler:    _HandlerPlugin_ReactionAdd_Handler,
+		},
+		{
+			MethodName: "ReactionRemove",
+			Handler:    _HandlerPlugin_ReactionRemove_Handler,
+		},
+		{
+			MethodName: "LinkAdd",
+			Handler:    _HandlerPlugin_LinkAdd_Handler,
+		},
+		{
+			MethodName: "LinkRemove",
+			Handler:    _HandlerPlugin_LinkRemove_Handler,
+		},
+		{
+			MethodName: "VerificationAdd",
+			Handler:    _HandlerPlugin_VerificationAdd_Handler,
+		},
+		{
+			MethodName: "VerificationRemove",
+			Handler:    _HandlerPlugin_VerificationRemove_Handler,
+		},
+		{
+			MethodName: "UserDataAdd",
+			Handler:    _HandlerPlugin_UserDataAdd_Handler,
+		},
+		{
+			MethodName: "UsernameProof",
+			Handler:    _HandlerPlugin_UsernameProof_Handler,
+		},
+		{
+			MethodName: "LinkCompactState",
+			Handler:    _HandlerPlugin_LinkCompactState_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugin.proto",
+}
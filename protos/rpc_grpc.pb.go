@@ -23,6 +23,18 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type HubServiceClient interface {
 	SubmitMessage(ctx context.Context, in *Message, opts ...grpc.CallOption) (*Message, error)
+	ValidateMessage(ctx context.Context, in *Message, opts ...grpc.CallOption) (*ValidationResponse, error)
+	// Event Stream
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (HubService_SubscribeClient, error)
+	// Casts
+	GetCast(ctx context.Context, in *CastId, opts ...grpc.CallOption) (*Message, error)
+	GetCastsByFid(ctx context.Context, in *FidRequest, opts ...grpc.CallOption) (*MessagesResponse, error)
+	// Reactions
+	GetReactionsByCast(ctx context.Context, in *ReactionsByTargetRequest, opts ...grpc.CallOption) (*MessagesResponse, error)
+	// Links
+	GetLinksByFid(ctx context.Context, in *LinksByFidRequest, opts ...grpc.CallOption) (*MessagesResponse, error)
+	// User Data
+	GetUserDataByFid(ctx context.Context, in *FidRequest, opts ...grpc.CallOption) (*MessagesResponse, error)
 }
 
 type hubServiceClient struct {
@@ -42,11 +54,109 @@ func (c *hubServiceClient) SubmitMessage(ctx context.Context, in *Message, opts
 	return out, nil
 }
 
+func (c *hubServiceClient) ValidateMessage(ctx context.Context, in *Message, opts ...grpc.CallOption) (*ValidationResponse, error) {
+	out := new(ValidationResponse)
+	err := c.cc.Invoke(ctx, "/HubService/ValidateMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hubServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (HubService_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &HubService_ServiceDesc.Streams[0], "/HubService/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &hubServiceSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type HubService_SubscribeClient interface {
+	Recv() (*HubEvent, error)
+	grpc.ClientStream
+}
+
+type hubServiceSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *hubServiceSubscribeClient) Recv() (*HubEvent, error) {
+	m := new(HubEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *hubServiceClient) GetCast(ctx context.Context, in *CastId, opts ...grpc.CallOption) (*Message, error) {
+	out := new(Message)
+	err := c.cc.Invoke(ctx, "/HubService/GetCast", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hubServiceClient) GetCastsByFid(ctx context.Context, in *FidRequest, opts ...grpc.CallOption) (*MessagesResponse, error) {
+	out := new(MessagesResponse)
+	err := c.cc.Invoke(ctx, "/HubService/GetCastsByFid", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hubServiceClient) GetReactionsByCast(ctx context.Context, in *ReactionsByTargetRequest, opts ...grpc.CallOption) (*MessagesResponse, error) {
+	out := new(MessagesResponse)
+	err := c.cc.Invoke(ctx, "/HubService/GetReactionsByCast", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hubServiceClient) GetLinksByFid(ctx context.Context, in *LinksByFidRequest, opts ...grpc.CallOption) (*MessagesResponse, error) {
+	out := new(MessagesResponse)
+	err := c.cc.Invoke(ctx, "/HubService/GetLinksByFid", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hubServiceClient) GetUserDataByFid(ctx context.Context, in *FidRequest, opts ...grpc.CallOption) (*MessagesResponse, error) {
+	out := new(MessagesResponse)
+	err := c.cc.Invoke(ctx, "/HubService/GetUserDataByFid", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // HubServiceServer is the server API for HubService service.
 // All implementations must embed UnimplementedHubServiceServer
 // for forward compatibility
 type HubServiceServer interface {
 	SubmitMessage(context.Context, *Message) (*Message, error)
+	ValidateMessage(context.Context, *Message) (*ValidationResponse, error)
+	// Event Stream
+	Subscribe(*SubscribeRequest, HubService_SubscribeServer) error
+	// Casts
+	GetCast(context.Context, *CastId) (*Message, error)
+	GetCastsByFid(context.Context, *FidRequest) (*MessagesResponse, error)
+	// Reactions
+	GetReactionsByCast(context.Context, *ReactionsByTargetRequest) (*MessagesResponse, error)
+	// Links
+	GetLinksByFid(context.Context, *LinksByFidRequest) (*MessagesResponse, error)
+	// User Data
+	GetUserDataByFid(context.Context, *FidRequest) (*MessagesResponse, error)
 	mustEmbedUnimplementedHubServiceServer()
 }
 
@@ -57,6 +167,27 @@ type UnimplementedHubServiceServer struct {
 func (UnimplementedHubServiceServer) SubmitMessage(context.Context, *Message) (*Message, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SubmitMessage not implemented")
 }
+func (UnimplementedHubServiceServer) ValidateMessage(context.Context, *Message) (*ValidationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateMessage not implemented")
+}
+func (UnimplementedHubServiceServer) Subscribe(*SubscribeRequest, HubService_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedHubServiceServer) GetCast(context.Context, *CastId) (*Message, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCast not implemented")
+}
+func (UnimplementedHubServiceServer) GetCastsByFid(context.Context, *FidRequest) (*MessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCastsByFid not implemented")
+}
+func (UnimplementedHubServiceServer) GetReactionsByCast(context.Context, *ReactionsByTargetRequest) (*MessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReactionsByCast not implemented")
+}
+func (UnimplementedHubServiceServer) GetLinksByFid(context.Context, *LinksByFidRequest) (*MessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLinksByFid not implemented")
+}
+func (UnimplementedHubServiceServer) GetUserDataByFid(context.Context, *FidRequest) (*MessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserDataByFid not implemented")
+}
 func (UnimplementedHubServiceServer) mustEmbedUnimplementedHubServiceServer() {}
 
 // UnsafeHubServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +219,135 @@ func _HubService_SubmitMessage_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _HubService_ValidateMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Message)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HubServiceServer).ValidateMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HubService/ValidateMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HubServiceServer).ValidateMessage(ctx, req.(*Message))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HubService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(HubServiceServer).Subscribe(m, &hubServiceSubscribeServer{stream})
+}
+
+type HubService_SubscribeServer interface {
+	Send(*HubEvent) error
+	grpc.ServerStream
+}
+
+type hubServiceSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *hubServiceSubscribeServer) Send(m *HubEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _HubService_GetCast_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CastId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HubServiceServer).GetCast(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HubService/GetCast",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HubServiceServer).GetCast(ctx, req.(*CastId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HubService_GetCastsByFid_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FidRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HubServiceServer).GetCastsByFid(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HubService/GetCastsByFid",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HubServiceServer).GetCastsByFid(ctx, req.(*FidRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HubService_GetReactionsByCast_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReactionsByTargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HubServiceServer).GetReactionsByCast(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HubService/GetReactionsByCast",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HubServiceServer).GetReactionsByCast(ctx, req.(*ReactionsByTargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HubService_GetLinksByFid_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LinksByFidRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HubServiceServer).GetLinksByFid(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HubService/GetLinksByFid",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HubServiceServer).GetLinksByFid(ctx, req.(*LinksByFidRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HubService_GetUserDataByFid_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FidRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HubServiceServer).GetUserDataByFid(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/HubService/GetUserDataByFid",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HubServiceServer).GetUserDataByFid(ctx, req.(*FidRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // HubService_ServiceDesc is the grpc.ServiceDesc for HubService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,7 +359,37 @@ var HubService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SubmitMessage",
 			Handler:    _HubService_SubmitMessage_Handler,
 		},
+		{
+			MethodName: "ValidateMessage",
+			Handler:    _HubService_ValidateMessage_Handler,
+		},
+		{
+			MethodName: "GetCast",
+			Handler:    _HubService_GetCast_Handler,
+		},
+		{
+			MethodName: "GetCastsByFid",
+			Handler:    _HubService_GetCastsByFid_Handler,
+		},
+		{
+			MethodName: "GetReactionsByCast",
+			Handler:    _HubService_GetReactionsByCast_Handler,
+		},
+		{
+			MethodName: "GetLinksByFid",
+			Handler:    _HubService_GetLinksByFid_Handler,
+		},
+		{
+			MethodName: "GetUserDataByFid",
+			Handler:    _HubService_GetUserDataByFid_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _HubService_Subscribe_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "rpc.proto",
 }
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	handler "github.com/noctisatrae/farseer/handlers"
+	protos "github.com/noctisatrae/farseer/protos"
+	utils "github.com/noctisatrae/farseer/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// The [handlers.redis] table of config.toml.
+//
+// ```toml
+// [handlers.redis]
+// Address = "redis:6379" # required
+// Password = "" # optional
+// DB = 0 # optional
+// StreamPrefix = "farseer:" # optional: the casts are added to farseer:cast_add, the reactions to farseer:reaction_add...
+// Encoding = "protobuf" # optional: protobuf or json, how the message is encoded in the entry
+// MaxLen = 100000 # optional: the streams are trimmed (approximately) to this many entries, 0 to keep everything
+// ConsumerGroups = ["workers"] # optional: created on every stream when the plugin starts, if they don't exist
+// ```
+type Config struct {
+	Address        string
+	Password       string
+	DB             int
+	StreamPrefix   string
+	Encoding       string
+	MaxLen         int64
+	ConsumerGroups []string
+}
+
+// Adds every message to a Redis stream per type of message. An entry holds:
+//   - hash: the hash of the message, in hex
+//   - fid: the user who sent it
+//   - timestamp: when it was sent, in farcaster time
+//   - message: the whole message, encoded as protobuf or JSON
+type Redis struct {
+	config Config
+	client *redis.Client
+}
+
+func (r *Redis) Name() string {
+	return "Redis"
+}
+
+func (r *Redis) Configure(raw []byte) error {
+	config := Config{
+		StreamPrefix: "farseer:",
+		Encoding:     handler.EncodingProtobuf,
+	}
+	if err := handler.Decode(raw, &config); err != nil {
+		return err
+	}
+	if config.Address == "" {
+		return errors.New("no Address was provided, so no connection can be made to Redis")
+	}
+	if err := handler.CheckEncoding(config.Encoding); err != nil {
+		return err
+	}
+	if config.MaxLen < 0 {
+		return errors.New("MaxLen can't be negative")
+	}
+
+	r.config = config
+	return nil
+}
+
+// Connects to Redis & creates the consumer groups.
+func (r *Redis) Init(ctx context.Context) error {
+	client := redis.NewClient(&redis.Options{
+		Addr:     r.config.Address,
+		Password: r.config.Password,
+		DB:       r.config.DB,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return err
+	}
+
+	for _, msgType := range r.MessageTypes() {
+		for _, group := range r.config.ConsumerGroups {
+			// the group only reads the messages added from now on
+			err := client.XGroupCreateMkStream(ctx, r.Stream(msgType), group, "$").Err()
+			if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+				client.Close()
+				return fmt.Errorf("couldn't create the consumer group %s: %w", group, err)
+			}
+		}
+	}
+
+	r.client = client
+	return nil
+}
+
+func (r *Redis) Close(ctx context.Context) error {
+	if r.client == nil {
+		return nil
+	}
+	err := r.client.Close()
+	r.client = nil
+	return err
+}
+
+// The stream the messages of this type are added to, e.g. farseer:cast_add.
+func (r *Redis) Stream(msgType protos.MessageType) string {
+	return r.config.StreamPrefix + strings.ToLower(strings.TrimPrefix(msgType.String(), "MESSAGE_TYPE_"))
+}
+
+func (r *Redis) HandleMessage(ctx context.Context, m *protos.Message, meta handler.MessageMeta) error {
+	encoded, err := handler.EncodeMessage(m, r.config.Encoding)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Adding the message to its stream! |", "Stream", r.Stream(m.Data.Type), "Hash", utils.BytesToHex(m.Hash))
+	return r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: r.Stream(m.Data.Type),
+		MaxLen: r.config.MaxLen,
+		Approx: true,
+		Values: []interface{}{
+			"hash", utils.BytesToHex(m.Hash),
+			"fid", strconv.FormatUint(m.Data.Fid, 10),
+			"timestamp", strconv.FormatUint(uint64(m.Data.Timestamp), 10),
+			"message", encoded,
+		},
+	}).Err()
+}
+
+// Every type of message, the relay only sends the ones matching the Filter of the plugin.
+func (r *Redis) MessageTypes() []protos.MessageType {
+	return handler.AllMessageTypes()
+}
+
+// Served by main, or looked up by the relay when the plugin is built as a shared library.
+var Plugin handler.Plugin = &Redis{}
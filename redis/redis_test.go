@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	handler "github.com/noctisatrae/farseer/handlers"
+	"github.com/noctisatrae/farseer/handlers/plugintest"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/charmbracelet/log"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Configures & connects the plugin to an in-memory Redis.
+func initPlugin(t *testing.T, config string) (*Redis, *miniredis.Miniredis) {
+	log.SetLevel(log.DebugLevel)
+	server := miniredis.RunT(t)
+
+	r := &Redis{}
+	plugintest.Start(t, r, "Address = '"+server.Addr()+"'\n"+config)
+
+	return r, server
+}
+
+func cast(text string) *protos.Message {
+	return &protos.Message{
+		Hash: []byte{1, 2, 3},
+		Data: &protos.MessageData{
+			Type:      protos.MessageType_MESSAGE_TYPE_CAST_ADD,
+			Fid:       10626,
+			Timestamp: 100,
+			Body:      &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: text}},
+		},
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	r := &Redis{}
+	assert.Error(t, r.Configure([]byte("")), "Address is required")
+	assert.Error(t, r.Configure([]byte("Address = 'localhost:6379'\nEncoding = 'xml'")))
+	assert.Error(t, r.Configure([]byte("Address = 'localhost:6379'\nMaxLn = 10")), "typos are caught")
+
+	assert.NoError(t, r.Configure([]byte("Address = 'localhost:6379'")))
+	assert.Equal(t, "farseer:cast_add", r.Stream(protos.MessageType_MESSAGE_TYPE_CAST_ADD))
+	assert.Equal(t, "farseer:verification_add_eth_address", r.Stream(protos.MessageType_MESSAGE_TYPE_VERIFICATION_ADD_ETH_ADDRESS))
+	assert.Len(t, r.MessageTypes(), 12)
+}
+
+func TestAddProtobuf(t *testing.T) {
+	r, server := initPlugin(t, "")
+
+	m := cast("gm")
+	assert.NoError(t, r.HandleMessage(context.Background(), m, handler.MessageMeta{}))
+
+	entries, err := server.Stream("farseer:cast_add")
+	assert.NoError(t, err)
+	if !assert.Len(t, entries, 1) {
+		t.FailNow()
+	}
+	values := entries[0].Values
+	assert.Equal(t, []string{"hash", "0x010203", "fid", "10626", "timestamp", "100"}, values[:6])
+
+	decoded := &protos.Message{}
+	assert.NoError(t, proto.Unmarshal([]byte(values[7]), decoded))
+	assert.Equal(t, "gm", decoded.Data.GetCastAddBody().Text)
+}
+
+func TestAddJSON(t *testing.T) {
+	r, server := initPlugin(t, "Encoding = 'json'\nStreamPrefix = 'hub:'")
+
+	assert.NoError(t, r.HandleMessage(context.Background(), cast("gn"), handler.MessageMeta{}))
+
+	entries, err := server.Stream("hub:cast_add")
+	assert.NoError(t, err)
+	if !assert.Len(t, entries, 1) {
+		t.FailNow()
+	}
+	decoded := &protos.Message{}
+	assert.NoError(t, protojson.Unmarshal([]byte(entries[0].Values[7]), decoded))
+	assert.Equal(t, "gn", decoded.Data.GetCastAddBody().Text)
+}
+
+// Are the streams trimmed?
+func TestMaxLen(t *testing.T) {
+	r, server := initPlugin(t, "MaxLen = 2")
+
+	for _, text := range []string{"1", "2", "3"} {
+		assert.NoError(t, r.HandleMessage(context.Background(), cast(text), handler.MessageMeta{}))
+	}
+	link := &protos.Message{Data: &protos.MessageData{Type: protos.MessageType_MESSAGE_TYPE_LINK_ADD}}
+	assert.NoError(t, r.HandleMessage(context.Background(), link, handler.MessageMeta{}))
+
+	entries, err := server.Stream("farseer:cast_add")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	entries, err = server.Stream("farseer:link_add")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+// Are the consumer groups created once, even when the plugin restarts?
+func TestConsumerGroups(t *testing.T) {
+	r, _ := initPlugin(t, "ConsumerGroups = ['workers']")
+
+	for _, stream := range []string{"farseer:cast_add", "farseer:reaction_add"} {
+		groups, err := r.client.XInfoGroups(context.Background(), stream).Result()
+		assert.NoError(t, err)
+		if assert.Len(t, groups, 1) {
+			assert.Equal(t, "workers", groups[0].Name)
+		}
+	}
+
+	assert.NoError(t, r.Close(context.Background()))
+	assert.NoError(t, r.Init(context.Background()))
+}
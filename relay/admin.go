@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+	"github.com/multiformats/go-multiaddr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// How long ConnectPeer waits for the connection.
+	adminConnectTimeout = 10 * time.Second
+	// How long GetPeers waits for the pings measuring the latency of the peers.
+	adminPingTimeout = 2 * time.Second
+)
+
+type adminRPCServer struct {
+	host    host.Host
+	ps      *pubsub.PubSub
+	tracker *gossipTracker
+	plugins *pluginRegistry
+	ll      log.Logger
+
+	protos.UnimplementedAdminServiceServer
+}
+
+func newAdminServer(h host.Host, ps *pubsub.PubSub, tracker *gossipTracker, ll log.Logger) *adminRPCServer {
+	return &adminRPCServer{
+		host:    h,
+		ps:      ps,
+		tracker: tracker,
+		plugins: loadedPlugins,
+		ll:      ll,
+	}
+}
+
+func (s *adminRPCServer) GetPeers(ctx context.Context, req *protos.AdminRequest) (*protos.PeersResponse, error) {
+	peers := s.host.Network().Peers()
+	slices.Sort(peers)
+
+	// the latency is only known once the peers were pinged
+	pingCtx, cancel := context.WithTimeout(ctx, adminPingTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, p := range peers {
+		wg.Add(1)
+		go func(p peer.ID) {
+			defer wg.Done()
+			select {
+			case <-ping.Ping(pingCtx, s.host, p):
+			case <-pingCtx.Done():
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	res := &protos.PeersResponse{Peers: []*protos.PeerInfo{}}
+	for _, p := range peers {
+		info := &protos.PeerInfo{
+			PeerId:    p.String(),
+			LatencyMs: float64(s.host.Peerstore().LatencyEWMA(p).Microseconds()) / 1000,
+		}
+		var connectedSince time.Time
+		for _, conn := range s.host.Network().ConnsToPeer(p) {
+			info.Multiaddrs = append(info.Multiaddrs, conn.RemoteMultiaddr().String())
+			if opened := conn.Stat().Opened; connectedSince.IsZero() || opened.Before(connectedSince) {
+				connectedSince = opened
+			}
+		}
+		if !connectedSince.IsZero() {
+			info.ConnectedSince = uint64(connectedSince.UnixMilli())
+		}
+		protocols, err := s.host.Peerstore().GetProtocols(p)
+		if err != nil {
+			s.ll.Debug("Couldn't get the protocols of a peer! |", "Peer", p, "Error", err)
+		}
+		for _, proto := range protocols {
+			info.Protocols = append(info.Protocols, string(proto))
+		}
+		slices.Sort(info.Protocols)
+		res.Peers = append(res.Peers, info)
+	}
+
+	return res, nil
+}
+
+func (s *adminRPCServer) GetTopics(ctx context.Context, req *protos.AdminRequest) (*protos.TopicsResponse, error) {
+	res := &protos.TopicsResponse{Topics: []*protos.TopicInfo{}}
+	for _, topic := range s.tracker.Topics() {
+		mesh, scores := s.tracker.Mesh(topic)
+		info := &protos.TopicInfo{
+			Topic:           topic,
+			MeshPeers:       []*protos.MeshPeer{},
+			SubscribedPeers: uint32(len(s.ps.ListPeers(topic))),
+		}
+		for _, p := range mesh {
+			info.MeshPeers = append(info.MeshPeers, &protos.MeshPeer{PeerId: p.String(), Score: scores[p]})
+		}
+		res.Topics = append(res.Topics, info)
+	}
+	return res, nil
+}
+
+// Reads the peer of a multiaddr ending with /p2p/<peer id>.
+func parsePeerAddress(addr string) (*peer.AddrInfo, error) {
+	maddr, err := multiaddr.NewMultiaddr(addr)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid multiaddr: %v", err)
+	}
+	info, err := peer.AddrInfoFromP2pAddr(maddr)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "the multiaddr must end with /p2p/<peer id>: %v", err)
+	}
+	return info, nil
+}
+
+func (s *adminRPCServer) ConnectPeer(ctx context.Context, req *protos.PeerAddressRequest) (*protos.AdminResponse, error) {
+	info, err := parsePeerAddress(req.Multiaddr)
+	if err != nil {
+		return nil, err
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, adminConnectTimeout)
+	defer cancel()
+	if err := s.host.Connect(connectCtx, *info); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "couldn't connect to %s: %v", info.ID, err)
+	}
+
+	s.ll.Info("Connected to a peer from the AdminService! |", "Peer", info.ID, "Multiaddr", req.Multiaddr)
+	return &protos.AdminResponse{}, nil
+}
+
+func (s *adminRPCServer) DisconnectPeer(ctx context.Context, req *protos.PeerAddressRequest) (*protos.AdminResponse, error) {
+	info, err := parsePeerAddress(req.Multiaddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.host.Network().Connectedness(info.ID) != network.Connected {
+		return nil, status.Errorf(codes.NotFound, "not connected to %s", info.ID)
+	}
+	if err := s.host.Network().ClosePeer(info.ID); err != nil {
+		return nil, status.Errorf(codes.Internal, "couldn't disconnect from %s: %v", info.ID, err)
+	}
+
+	s.ll.Info("Disconnected from a peer from the AdminService! |", "Peer", info.ID)
+	return &protos.AdminResponse{}, nil
+}
+
+func (s *adminRPCServer) GetPlugins(ctx context.Context, req *protos.AdminRequest) (*protos.PluginsResponse, error) {
+	return &protos.PluginsResponse{Plugins: s.plugins.List()}, nil
+}
+
+// Serves the AdminService on address until stopCh is closed. Nothing is served when address is empty.
+func StartAdmin(wg *sync.WaitGroup, stopCh <-chan struct{}, address string, admin *adminRPCServer, ll log.Logger) {
+	defer wg.Done()
+	if strings.TrimSpace(address) == "" {
+		return
+	}
+
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		ll.Error("Can't start the listener of the AdminService! |", "Address", address, "Error", err)
+		return
+	}
+
+	grpcServer := grpc.NewServer()
+	protos.RegisterAdminServiceServer(grpcServer, admin)
+	reflection.Register(grpcServer)
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			ll.Error("Couldn't serve the AdminService! |", "Error", err)
+		}
+	}()
+	ll.Info("Started the AdminService! |", "Address", lis.Addr())
+
+	<-stopCh
+
+	grpcServer.GracefulStop()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	"github.com/libp2p/go-libp2p"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestHost(t *testing.T, opts ...pubsub.Option) (host.Host, *pubsub.PubSub) {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"), libp2p.Ping(true))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { h.Close() })
+
+	ps, err := pubsub.NewGossipSub(context.Background(), h, opts...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	topic, err := ps.Join("f_network_1_primary")
+	assert.NoError(t, err)
+	_, err = topic.Subscribe()
+	assert.NoError(t, err)
+	return h, ps
+}
+
+// Can the peers & the mesh of the hub be inspected, and the peers connected & disconnected?
+func TestAdminPeers(t *testing.T) {
+	tracker := newGossipTracker()
+	hub, ps := newTestHost(t, tracker.Options()...)
+	remote, _ := newTestHost(t)
+	remoteAddr := fmt.Sprintf("%s/p2p/%s", remote.Addrs()[0], remote.ID())
+
+	s := newAdminServer(hub, ps, tracker, *log.Default())
+	ctx := context.Background()
+
+	_, err := s.ConnectPeer(ctx, &protos.PeerAddressRequest{Multiaddr: remoteAddr})
+	assert.NoError(t, err)
+
+	peers, err := s.GetPeers(ctx, &protos.AdminRequest{})
+	assert.NoError(t, err)
+	if assert.Len(t, peers.Peers, 1) {
+		assert.Equal(t, remote.ID().String(), peers.Peers[0].PeerId)
+		assert.Equal(t, []string{remote.Addrs()[0].String()}, peers.Peers[0].Multiaddrs)
+		assert.Contains(t, peers.Peers[0].Protocols, string(pubsub.GossipSubID_v11))
+		assert.InDelta(t, time.Now().UnixMilli(), peers.Peers[0].ConnectedSince, 10000)
+		assert.Greater(t, peers.Peers[0].LatencyMs, 0.0)
+	}
+
+	// the remote peer is grafted on the mesh at the next heartbeat
+	assert.Eventually(t, func() bool {
+		topics, err := s.GetTopics(ctx, &protos.AdminRequest{})
+		return err == nil && len(topics.Topics) == 1 && len(topics.Topics[0].MeshPeers) == 1
+	}, 5*time.Second, 100*time.Millisecond)
+	topics, _ := s.GetTopics(ctx, &protos.AdminRequest{})
+	assert.Equal(t, "f_network_1_primary", topics.Topics[0].Topic)
+	assert.Equal(t, uint32(1), topics.Topics[0].SubscribedPeers)
+	assert.Equal(t, remote.ID().String(), topics.Topics[0].MeshPeers[0].PeerId)
+
+	_, err = s.DisconnectPeer(ctx, &protos.PeerAddressRequest{Multiaddr: remoteAddr})
+	assert.NoError(t, err)
+	peers, err = s.GetPeers(ctx, &protos.AdminRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, peers.Peers)
+
+	_, err = s.DisconnectPeer(ctx, &protos.PeerAddressRequest{Multiaddr: remoteAddr})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// Are the invalid multiaddrs refused?
+func TestAdminInvalidMultiaddr(t *testing.T) {
+	tracker := newGossipTracker()
+	hub, ps := newTestHost(t, tracker.Options()...)
+	s := newAdminServer(hub, ps, tracker, *log.Default())
+
+	for _, addr := range []string{"", "not a multiaddr", "/ip4/127.0.0.1/tcp/2282"} {
+		_, err := s.ConnectPeer(context.Background(), &protos.PeerAddressRequest{Multiaddr: addr})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), addr)
+	}
+}
+
+// Are the plugins reported with the counters of their handlers?
+func TestAdminPlugins(t *testing.T) {
+	s := &adminRPCServer{plugins: newPluginRegistry()}
+	s.plugins.Starting("redis", pluginKindProcess)
+	s.plugins.Starting("postgresql", pluginKindSharedLibrary)
+	s.plugins.Initialized("postgresql", nil)
+	s.plugins.Initialized("redis", errors.New("connection refused"))
+	s.plugins.Handled("postgresql", protos.MessageType_MESSAGE_TYPE_REACTION_ADD, nil)
+	s.plugins.Handled("postgresql", protos.MessageType_MESSAGE_TYPE_CAST_ADD, nil)
+	s.plugins.Handled("postgresql", protos.MessageType_MESSAGE_TYPE_CAST_ADD, errors.New("duplicate key"))
+
+	res, err := s.GetPlugins(context.Background(), &protos.AdminRequest{})
+	assert.NoError(t, err)
+	if !assert.Len(t, res.Plugins, 2) {
+		return
+	}
+
+	postgres := res.Plugins[0]
+	assert.Equal(t, "postgresql", postgres.Name)
+	assert.Equal(t, pluginKindSharedLibrary, postgres.Kind)
+	assert.True(t, postgres.Initialized)
+	if assert.Len(t, postgres.Handlers, 2) {
+		assert.Equal(t, protos.MessageType_MESSAGE_TYPE_CAST_ADD, postgres.Handlers[0].MessageType)
+		assert.Equal(t, uint64(2), postgres.Handlers[0].Handled)
+		assert.Equal(t, uint64(1), postgres.Handlers[0].Errors)
+		assert.Equal(t, uint64(1), postgres.Handlers[1].Handled)
+	}
+
+	redis := res.Plugins[1]
+	assert.Equal(t, pluginKindProcess, redis.Kind)
+	assert.False(t, redis.Initialized)
+	assert.Equal(t, "connection refused", redis.InitError)
+}
+
+// Are the scores kept positive for GossipSub but inspected as penalties?
+func TestGossipScores(t *testing.T) {
+	tracker := newGossipTracker()
+	tracker.Join("f_network_1_primary")
+	tracker.Graft("well-behaved", "f_network_1_primary")
+	tracker.Graft("misbehaving", "f_network_1_primary")
+	tracker.updateScores(map[peer.ID]float64{"well-behaved": gossipScoreOffset, "misbehaving": gossipScoreOffset - 40})
+
+	_, scores := tracker.Mesh("f_network_1_primary")
+	assert.Equal(t, map[peer.ID]float64{"well-behaved": 0, "misbehaving": -40}, scores)
+	assert.Zero(t, gossipSubParams().Dscore)
+}
@@ -1,58 +1,127 @@
 package main
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	protos "github.com/noctisatrae/farseer/protos"
 
 	"github.com/charmbracelet/log"
-	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
-	"github.com/multiformats/go-multiaddr"
+	"google.golang.org/protobuf/proto"
 )
 
 // Channel => Message => Content
-func HandleContactInfo(contactInfoChan chan *protos.GossipMessage, ll log.Logger, h host.Host, ctx context.Context) {
+func HandleContactInfo(contactInfoChan chan *protos.GossipMessage, ll log.Logger, book *PeerBook) {
 	for contactInfoMessage := range contactInfoChan {
-		remotePeerId, err := peer.IDFromBytes(contactInfoMessage.GetPeerId())
+		contact, err := book.Add(contactInfoMessage)
 		if err != nil {
-			ll.Error("Can't serialize the peer id from message! |", "Error", err)
-		} else {
-			remotePeerIdStr := remotePeerId.String()
-			cinfo := contactInfoMessage.GetContactInfoContent()
-
-			remotePeerAddrFamily := cinfo.GossipAddress.GetFamily()
-			remotePeerAddr := cinfo.GossipAddress.GetAddress()
-			remotePeerPort := cinfo.GossipAddress.GetPort()
-
-			ll.Info("Received contact info! |", "Addr", remotePeerAddr, "Port", remotePeerPort, "Family", remotePeerAddrFamily)
-
-			remotePeerMultiAddr, err := multiaddr.NewMultiaddr(fmt.Sprintf(
-				"/ip%s/%s/tcp/%s/p2p/%s",
-				strconv.FormatUint(uint64(remotePeerAddrFamily), 10),
-				remotePeerAddr,
-				strconv.FormatUint(uint64(remotePeerPort), 10),
-				remotePeerIdStr,
-			))
-			ll.Debug("From contact info! |", "Multiaddr", remotePeerMultiAddr)
+			ll.Debug("Ignored a contact info! |", "Error", err)
+			continue
+		}
+		ll.Debug("Received contact info! |", "Peer", contact.PeerId, "Addr", contact.Body.GetGossipAddress().GetAddress(), "Port", contact.Body.GetGossipAddress().GetPort())
+	}
+}
+
+// Checks that the contact info was signed by the peer that sent it & returns it with its body, decoded from the
+// data bytes that were signed when there are some.
+func verifyContactInfo(m *protos.GossipMessage) (peer.ID, *protos.ContactInfoContentBody, error) {
+	cinfo := m.GetContactInfoContent()
+	if cinfo == nil {
+		return "", nil, errors.New("not a contact info")
+	}
+
+	peerId, err := peer.IDFromBytes(m.GetPeerId())
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid peer id: %w", err)
+	}
+	if len(cinfo.Signature) == 0 || len(cinfo.Signer) == 0 {
+		return "", nil, fmt.Errorf("the contact info of %s isn't signed", peerId)
+	}
+
+	signer, err := crypto.UnmarshalPublicKey(cinfo.Signer)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid signer: %w", err)
+	}
+	if !peerId.MatchesPublicKey(signer) {
+		return "", nil, fmt.Errorf("the contact info of %s is signed by another peer", peerId)
+	}
+
+	dataBytes := cinfo.DataBytes
+	body := cinfo.Body
+	if len(dataBytes) > 0 {
+		body = &protos.ContactInfoContentBody{}
+		if err := proto.Unmarshal(dataBytes, body); err != nil {
+			return "", nil, fmt.Errorf("invalid data bytes: %w", err)
+		}
+	} else {
+		if body == nil {
+			return "", nil, fmt.Errorf("the contact info of %s has no body", peerId)
+		}
+		dataBytes, err = proto.Marshal(body)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	valid, err := signer.Verify(dataBytes, cinfo.Signature)
+	if err != nil || !valid {
+		return "", nil, fmt.Errorf("invalid signature of the contact info of %s", peerId)
+	}
+
+	return peerId, body, nil
+}
+
+// Compares two versions of the protocol like 2024.7.24: negative when a is older than b, positive when it's newer.
+func compareHubVersions(a string, b string) (int, error) {
+	parse := func(version string) ([]int, error) {
+		parts := strings.Split(version, ".")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid hub version %q", version)
+		}
+		numbers := make([]int, len(parts))
+		for i, part := range parts {
+			n, err := strconv.Atoi(part)
 			if err != nil {
-				ll.Error("Can't parse multiaddrr from contact info! |", "Error", err)
-			} else {
-
-				remotePeerAddrInfo, err := peer.AddrInfoFromP2pAddr(remotePeerMultiAddr)
-				if err != nil {
-					ll.Error("Can't create AddrInfo from contact info! |", "Error", err, "Multiaddr", remotePeerMultiAddr)
-				} else {
-					err = h.Connect(ctx, *remotePeerAddrInfo)
-					if err != nil {
-						ll.Error("Couldn't connect to peer from contact info! |", "Error", err)
-					} else {
-						ll.Info("Connected to peer from contact info! |", "Addr", remotePeerAddr)
-					}
-				}
+				return nil, fmt.Errorf("invalid hub version %q", version)
 			}
+			numbers[i] = n
+		}
+		return numbers, nil
+	}
+
+	va, err := parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parse(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] - vb[i], nil
+		}
+	}
+	return 0, nil
+}
+
+// The gossip address of the body as a multiaddr, its DNS name is only used when the address can't be read.
+func gossipMultiaddr(id peer.ID, body *protos.ContactInfoContentBody) (string, error) {
+	address := body.GetGossipAddress()
+	if address.GetPort() == 0 {
+		return "", errors.New("no gossip port")
+	}
+
+	family, err := addressFamily(address.GetAddress())
+	if err != nil {
+		if address.GetDnsName() == "" {
+			return "", err
 		}
+		return fmt.Sprintf("/dns/%s/tcp/%d/p2p/%s", address.GetDnsName(), address.GetPort(), id), nil
 	}
+	return fmt.Sprintf("/ip%d/%s/tcp/%d/p2p/%s", family, address.GetAddress(), address.GetPort(), id), nil
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/noctisatrae/farseer/config"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"google.golang.org/protobuf/proto"
+)
+
+// 4 for an IPv4 address, 6 for an IPv6 one.
+func addressFamily(address string) (uint32, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return 0, fmt.Errorf("%q isn't an IP address", address)
+	}
+	if ip.To4() != nil {
+		return 4, nil
+	}
+	return 6, nil
+}
+
+// Can the other hubs reach the gRPC API listening on this host? Empty means every interface.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// The contact info announced by the hub on the contact_info topic. Like Hubble does, the encoded body is signed with
+// the identity of the hub so the other hubs can check it was sent by the peer it claims to be from. The hub can be
+// announced by its DNS name only, & its RPC address is left out while the gRPC API only listens on a loopback host.
+func NewContactInfo(hub config.HubParams, privKey crypto.PrivKey, now time.Time) (*protos.ContactInfoContent, error) {
+	if hub.PublicHubIp == "" && hub.PublicDnsName == "" {
+		return nil, errors.New("neither PublicHubIp nor PublicDnsName is set, the other hubs can't reach this one")
+	}
+	var family uint32
+	if hub.PublicHubIp != "" {
+		var err error
+		family, err = addressFamily(hub.PublicHubIp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PublicHubIp: %w", err)
+		}
+	}
+
+	gossipAddress := &protos.GossipAddressInfo{
+		Address: hub.PublicHubIp,
+		Family:  family,
+		Port:    uint32(hub.GossipPort),
+		DnsName: hub.PublicDnsName,
+	}
+	var rpcAddress *protos.GossipAddressInfo
+	if !isLoopbackHost(hub.RpcHost) {
+		rpcAddress = &protos.GossipAddressInfo{
+			Address: hub.PublicHubIp,
+			Family:  family,
+			Port:    uint32(hub.RpcPort),
+			DnsName: hub.PublicDnsName,
+		}
+	}
+	body := &protos.ContactInfoContentBody{
+		GossipAddress: gossipAddress,
+		RpcAddress:    rpcAddress,
+		HubVersion:    HUB_VERSION,
+		Network:       protos.FarcasterNetwork(hub.Network),
+		AppVersion:    APP_VERSION,
+		Timestamp:     uint64(now.UnixMilli()),
+	}
+
+	dataBytes, err := proto.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encode the contact info: %w", err)
+	}
+	signature, err := privKey.Sign(dataBytes)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't sign the contact info: %w", err)
+	}
+	signer, err := crypto.MarshalPublicKey(privKey.GetPublic())
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encode the public key of the hub: %w", err)
+	}
+
+	// the fields of the body are repeated for the hubs that don't read it yet
+	return &protos.ContactInfoContent{
+		GossipAddress: gossipAddress,
+		RpcAddress:    rpcAddress,
+		HubVersion:    body.HubVersion,
+		Network:       body.Network,
+		AppVersion:    body.AppVersion,
+		Timestamp:     body.Timestamp,
+		Body:          body,
+		Signature:     signature,
+		Signer:        signer,
+		DataBytes:     dataBytes,
+	}, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/noctisatrae/farseer/config"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+// Is the contact info complete & signed by the identity of the hub?
+func TestNewContactInfo(t *testing.T) {
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	assert.NoError(t, err)
+	hub := config.HubParams{
+		PublicHubIp:   "92.158.95.48",
+		PublicDnsName: "hub.example.com",
+		GossipPort:    2282,
+		RpcPort:       2283,
+		RpcHost:       "0.0.0.0",
+		Network:       2,
+	}
+	now := time.UnixMilli(1722000000123)
+
+	contactInfo, err := NewContactInfo(hub, privKey, now)
+	assert.NoError(t, err)
+
+	body := contactInfo.Body
+	assert.True(t, proto.Equal(&protos.GossipAddressInfo{Address: "92.158.95.48", Family: 4, Port: 2282, DnsName: "hub.example.com"}, body.GossipAddress))
+	assert.True(t, proto.Equal(&protos.GossipAddressInfo{Address: "92.158.95.48", Family: 4, Port: 2283, DnsName: "hub.example.com"}, body.RpcAddress))
+	assert.Equal(t, protos.FarcasterNetwork_FARCASTER_NETWORK_TESTNET, body.Network)
+	assert.Equal(t, HUB_VERSION, body.HubVersion)
+	assert.Equal(t, APP_VERSION, body.AppVersion)
+	assert.Equal(t, uint64(1722000000123), body.Timestamp)
+	assert.Equal(t, body.Timestamp, contactInfo.Timestamp)
+	assert.Equal(t, body.RpcAddress, contactInfo.RpcAddress)
+
+	// the data bytes are the body that was signed
+	decoded := &protos.ContactInfoContentBody{}
+	assert.NoError(t, proto.Unmarshal(contactInfo.DataBytes, decoded))
+	assert.True(t, proto.Equal(body, decoded))
+
+	signer, err := crypto.UnmarshalPublicKey(contactInfo.Signer)
+	assert.NoError(t, err)
+	assert.True(t, signer.Equals(privKey.GetPublic()))
+	valid, err := signer.Verify(contactInfo.DataBytes, contactInfo.Signature)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// the signer is the peer sending the contact info
+	signerId, err := peer.IDFromPublicKey(signer)
+	assert.NoError(t, err)
+	selfId, err := peer.IDFromPrivateKey(privKey)
+	assert.NoError(t, err)
+	assert.Equal(t, selfId, signerId)
+}
+
+// Is the family of the address detected?
+func TestContactInfoAddressFamily(t *testing.T) {
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	assert.NoError(t, err)
+
+	contactInfo, err := NewContactInfo(config.HubParams{PublicHubIp: "2001:db8::1", GossipPort: 2282, Network: 1}, privKey, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(6), contactInfo.Body.GossipAddress.Family)
+	assert.Equal(t, uint32(6), contactInfo.Body.RpcAddress.Family)
+	assert.Equal(t, "", contactInfo.Body.GossipAddress.DnsName)
+
+	contactInfo, err = NewContactInfo(config.HubParams{PublicHubIp: "::ffff:10.0.0.1"}, privKey, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(4), contactInfo.Body.GossipAddress.Family)
+
+	_, err = NewContactInfo(config.HubParams{PublicHubIp: "hub.example.com"}, privKey, time.Now())
+	assert.ErrorContains(t, err, "invalid PublicHubIp")
+}
+
+// Can the hub be announced by its DNS name only & is the RPC address left out while the gRPC API is loopback-only?
+func TestContactInfoAddresses(t *testing.T) {
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	assert.NoError(t, err)
+
+	contactInfo, err := NewContactInfo(config.HubParams{PublicDnsName: "hub.example.com", GossipPort: 2282, RpcPort: 2283}, privKey, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(&protos.GossipAddressInfo{Port: 2282, DnsName: "hub.example.com"}, contactInfo.Body.GossipAddress))
+	assert.True(t, proto.Equal(&protos.GossipAddressInfo{Port: 2283, DnsName: "hub.example.com"}, contactInfo.Body.RpcAddress))
+	id, err := peer.IDFromPrivateKey(privKey)
+	assert.NoError(t, err)
+	addr, err := gossipMultiaddr(id, contactInfo.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "/dns/hub.example.com/tcp/2282/p2p/"+id.String(), addr)
+
+	for _, host := range []string{"localhost", "127.0.0.1", "::1"} {
+		contactInfo, err = NewContactInfo(config.HubParams{PublicHubIp: "92.158.95.48", RpcHost: host}, privKey, time.Now())
+		assert.NoError(t, err)
+		assert.Nil(t, contactInfo.Body.RpcAddress, host)
+		assert.Nil(t, contactInfo.RpcAddress, host)
+		assert.NotNil(t, contactInfo.Body.GossipAddress, host)
+	}
+
+	_, err = NewContactInfo(config.HubParams{GossipPort: 2282}, privKey, time.Now())
+	assert.ErrorContains(t, err, "neither PublicHubIp nor PublicDnsName")
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/noctisatrae/farseer/filter"
+	"github.com/noctisatrae/farseer/handlers"
+	protos "github.com/noctisatrae/farseer/protos"
+	"github.com/noctisatrae/farseer/utils"
+	"github.com/noctisatrae/farseer/validation"
+
+	"github.com/charmbracelet/log"
+)
+
+// What a subscriber does with a new message when its buffer is full.
+type Backpressure string
+
+const (
+	// Wait until the subscriber has room: a slow subscriber slows down every other one.
+	BackpressureBlock Backpressure = "block"
+	// Evict the oldest buffered message to make room for the new one.
+	BackpressureDropOldest Backpressure = "drop-oldest"
+	// Discard the new message and keep the buffer as it is.
+	BackpressureDropNewest Backpressure = "drop-newest"
+)
+
+func ParseBackpressure(policy string) (Backpressure, error) {
+	switch Backpressure(policy) {
+	case BackpressureBlock, BackpressureDropOldest, BackpressureDropNewest:
+		return Backpressure(policy), nil
+	default:
+		return "", fmt.Errorf("unknown backpressure policy %q, expected one of block, drop-oldest or drop-newest", policy)
+	}
+}
+
+// Whether a subscriber only wants "verified" messages or all of them, "raw".
+func ParseValidation(validation string) (bool, error) {
+	switch validation {
+	case "verified":
+		return true, nil
+	case "raw":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown validation mode %q, expected verified or raw", validation)
+	}
+}
+
+type SubscribeOptions struct {
+	BufferSize   uint
+	Backpressure Backpressure
+	// Only receive the messages with a valid hash & signature. Gossip messages with no valid message are skipped
+	// and bundles are trimmed down to their valid messages.
+	VerifiedOnly bool
+	// Only receive the messages matching the filter, trimming the bundles the same way. Nil for every message.
+	Filter *filter.Filter
+}
+
+// A consumer of the dispatcher with its own buffer. Messages are shared between subscribers so they must be
+// treated as read-only.
+type Subscriber struct {
+	Name     string
+	Messages chan *protos.GossipMessage
+
+	policy       Backpressure
+	verifiedOnly bool
+	filter       *filter.Filter
+	dropped      atomic.Uint64
+}
+
+// How many messages were discarded because the subscriber couldn't keep up.
+func (sub *Subscriber) Dropped() uint64 {
+	return sub.dropped.Load()
+}
+
+// Counts a message discarded because the subscriber couldn't keep up.
+func (sub *Subscriber) drop() {
+	sub.dropped.Add(1)
+	subscriberDropped.WithLabelValues(sub.Name).Inc()
+}
+
+func (sub *Subscriber) send(msg *protos.GossipMessage) {
+	switch sub.policy {
+	case BackpressureDropNewest:
+		select {
+		case sub.Messages <- msg:
+		default:
+			sub.drop()
+		}
+	case BackpressureDropOldest:
+		for {
+			select {
+			case sub.Messages <- msg:
+				return
+			default:
+			}
+
+			// the buffer is full: make room by evicting the oldest message then retry
+			select {
+			case <-sub.Messages:
+				sub.drop()
+			default:
+			}
+		}
+	default:
+		sub.Messages <- msg
+	}
+}
+
+// Fans out every message read from a network to all of its subscribers so each of them sees the full stream.
+type Dispatcher struct {
+	mu          sync.RWMutex
+	subscribers []*Subscriber
+	closed      bool
+
+	// how many messages failed validation, by reason
+	rejectionsMu sync.Mutex
+	rejections   map[string]uint64
+
+	ll log.Logger
+}
+
+func NewDispatcher(ll log.Logger) *Dispatcher {
+	return &Dispatcher{
+		subscribers: []*Subscriber{},
+		rejections:  map[string]uint64{},
+		ll:          ll,
+	}
+}
+
+// Registers a new subscriber with a buffer of the given size. Its channel is closed when the dispatcher stops
+// or when it's unsubscribed.
+func (d *Dispatcher) Subscribe(name string, opts SubscribeOptions) *Subscriber {
+	sub := &Subscriber{
+		Name:         name,
+		Messages:     make(chan *protos.GossipMessage, opts.BufferSize),
+		policy:       opts.Backpressure,
+		verifiedOnly: opts.VerifiedOnly,
+		filter:       opts.Filter,
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		close(sub.Messages)
+		return sub
+	}
+	d.subscribers = append(d.subscribers, sub)
+
+	d.ll.Debug("New subscriber to the dispatcher! |", "Name", name, "BufferSize", opts.BufferSize, "Backpressure", opts.Backpressure, "VerifiedOnly", opts.VerifiedOnly, "Filter", opts.Filter.String())
+	return sub
+}
+
+func (d *Dispatcher) Unsubscribe(sub *Subscriber) {
+	// a full subscriber with the block policy holds up the dispatch, which holds the lock: drain it until it's closed
+	go func() {
+		for range sub.Messages {
+		}
+	}()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i, el := range d.subscribers {
+		if el == sub {
+			d.subscribers = append(d.subscribers[:i], d.subscribers[i+1:]...)
+			close(sub.Messages)
+			return
+		}
+	}
+}
+
+// Reads the messages until the channel is closed, then closes the channels of the subscribers.
+func (d *Dispatcher) Run(messages chan *protos.GossipMessage) {
+	for msg := range messages {
+		d.Dispatch(msg)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, sub := range d.subscribers {
+		close(sub.Messages)
+	}
+	d.subscribers = nil
+	d.closed = true
+}
+
+func (d *Dispatcher) Dispatch(msg *protos.GossipMessage) {
+	verified := d.verify(msg)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, sub := range d.subscribers {
+		toSend := msg
+		if sub.verifiedOnly {
+			toSend = verified
+		}
+		if toSend != nil && sub.filter != nil {
+			toSend = trimGossip(toSend, sub.filter.Match)
+		}
+		if toSend == nil {
+			continue
+		}
+
+		before := sub.Dropped()
+		sub.send(toSend)
+		if sub.Dropped() != before {
+			d.ll.Debug("Subscriber is full, dropped a message! |", "Name", sub.Name, "Backpressure", sub.policy, "Dropped", sub.Dropped())
+		}
+	}
+}
+
+// How many messages were rejected by the validation, by reason.
+func (d *Dispatcher) Rejections() map[string]uint64 {
+	d.rejectionsMu.Lock()
+	defer d.rejectionsMu.Unlock()
+
+	rejections := make(map[string]uint64, len(d.rejections))
+	for reason, count := range d.rejections {
+		rejections[reason] = count
+	}
+	return rejections
+}
+
+// Validates the messages of a gossip message. It returns the gossip message itself if they're all valid, a copy with
+// only the valid ones otherwise, or nil if none of them is.
+func (d *Dispatcher) verify(msg *protos.GossipMessage) *protos.GossipMessage {
+	return trimGossip(msg, func(m *protos.Message) bool {
+		err := validation.DecodeData(m)
+		if err == nil {
+			err = validation.ValidateMessage(m)
+		}
+		if err != nil {
+			reason := validation.Reason(err)
+			d.rejectionsMu.Lock()
+			d.rejections[reason]++
+			d.rejectionsMu.Unlock()
+
+			d.ll.Debug("Rejected an invalid message! |", "Reason", reason, "Hash", utils.BytesToHex(m.Hash), "Fid", m.GetData().GetFid())
+			return false
+		}
+		return true
+	})
+}
+
+// Keeps the messages of a gossip message for which keep is true. It returns the gossip message itself if they're all
+// kept, a bundle with only the kept ones otherwise, or nil if none of them is.
+func trimGossip(msg *protos.GossipMessage, keep func(m *protos.Message) bool) *protos.GossipMessage {
+	msgs, meta := handlers.ExtractMessages(msg)
+
+	kept := make([]*protos.Message, 0, len(msgs))
+	for _, m := range msgs {
+		if keep(m) {
+			kept = append(kept, m)
+		}
+	}
+
+	if len(kept) == 0 {
+		return nil
+	} else if len(kept) == len(msgs) {
+		return msg
+	}
+
+	return &protos.GossipMessage{
+		Content: &protos.GossipMessage_MessageBundle{
+			MessageBundle: &protos.MessageBundle{
+				Hash:     meta.BundleHash,
+				Messages: kept,
+			},
+		},
+		Topics:    msg.Topics,
+		PeerId:    msg.PeerId,
+		Version:   msg.Version,
+		Timestamp: msg.Timestamp,
+	}
+}
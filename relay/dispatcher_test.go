@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"os"
+	"testing"
+
+	"github.com/noctisatrae/farseer/filter"
+	protos "github.com/noctisatrae/farseer/protos"
+	"github.com/noctisatrae/farseer/validation"
+
+	"github.com/charmbracelet/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func gossipWithTimestamp(timestamp uint32) *protos.GossipMessage {
+	return &protos.GossipMessage{Timestamp: timestamp}
+}
+
+// Does every subscriber get every message?
+func TestDispatcherFanOut(t *testing.T) {
+	ll := *log.NewWithOptions(os.Stderr, log.Options{})
+	d := NewDispatcher(ll)
+
+	first := d.Subscribe("first", SubscribeOptions{BufferSize: 4, Backpressure: BackpressureBlock})
+	second := d.Subscribe("second", SubscribeOptions{BufferSize: 4, Backpressure: BackpressureBlock})
+
+	messages := make(chan *protos.GossipMessage)
+	go d.Run(messages)
+
+	for i := uint32(1); i <= 3; i++ {
+		messages <- gossipWithTimestamp(i)
+	}
+	close(messages)
+
+	for _, sub := range []*Subscriber{first, second} {
+		received := []uint32{}
+		for msg := range sub.Messages {
+			received = append(received, msg.Timestamp)
+		}
+		assert.Equal(t, []uint32{1, 2, 3}, received, sub.Name)
+	}
+}
+
+func TestDispatcherDropNewest(t *testing.T) {
+	ll := *log.NewWithOptions(os.Stderr, log.Options{})
+	d := NewDispatcher(ll)
+	sub := d.Subscribe("drop_newest", SubscribeOptions{BufferSize: 2, Backpressure: BackpressureDropNewest})
+
+	for i := uint32(1); i <= 4; i++ {
+		d.Dispatch(gossipWithTimestamp(i))
+	}
+
+	assert.Equal(t, uint64(2), sub.Dropped())
+	assert.Equal(t, 2.0, testutil.ToFloat64(subscriberDropped.WithLabelValues("drop_newest")))
+	assert.Equal(t, uint32(1), (<-sub.Messages).Timestamp)
+	assert.Equal(t, uint32(2), (<-sub.Messages).Timestamp)
+}
+
+func TestDispatcherDropOldest(t *testing.T) {
+	ll := *log.NewWithOptions(os.Stderr, log.Options{})
+	d := NewDispatcher(ll)
+	sub := d.Subscribe("slow", SubscribeOptions{BufferSize: 2, Backpressure: BackpressureDropOldest})
+
+	for i := uint32(1); i <= 4; i++ {
+		d.Dispatch(gossipWithTimestamp(i))
+	}
+
+	assert.Equal(t, uint64(2), sub.Dropped())
+	assert.Equal(t, uint32(3), (<-sub.Messages).Timestamp)
+	assert.Equal(t, uint32(4), (<-sub.Messages).Timestamp)
+}
+
+// Is the channel of a subscriber closed when it leaves?
+func TestDispatcherUnsubscribe(t *testing.T) {
+	ll := *log.NewWithOptions(os.Stderr, log.Options{})
+	d := NewDispatcher(ll)
+	sub := d.Subscribe("leaving", SubscribeOptions{BufferSize: 1, Backpressure: BackpressureBlock})
+
+	d.Unsubscribe(sub)
+	_, ok := <-sub.Messages
+	assert.False(t, ok)
+
+	// dispatching without subscribers must not block
+	d.Dispatch(gossipWithTimestamp(1))
+}
+
+func TestParseBackpressure(t *testing.T) {
+	policy, err := ParseBackpressure("drop-oldest")
+	assert.NoError(t, err)
+	assert.Equal(t, BackpressureDropOldest, policy)
+
+	_, err = ParseBackpressure("whatever")
+	assert.Error(t, err)
+}
+
+// Are invalid messages kept away from the subscribers that only want verified ones?
+func TestDispatcherVerifiedOnly(t *testing.T) {
+	ll := *log.NewWithOptions(os.Stderr, log.Options{})
+	d := NewDispatcher(ll)
+
+	raw := d.Subscribe("raw", SubscribeOptions{BufferSize: 2, Backpressure: BackpressureBlock})
+	verified := d.Subscribe("verified", SubscribeOptions{BufferSize: 2, Backpressure: BackpressureBlock, VerifiedOnly: true})
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	data := &protos.MessageData{Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD, Fid: 10626}
+	dataBytes, err := proto.Marshal(data)
+	assert.NoError(t, err)
+	hash := validation.Hash(dataBytes)
+
+	valid := &protos.Message{
+		Data:            data,
+		Hash:            hash,
+		HashScheme:      protos.HashScheme_HASH_SCHEME_BLAKE3,
+		Signature:       ed25519.Sign(priv, hash),
+		SignatureScheme: protos.SignatureScheme_SIGNATURE_SCHEME_ED25519,
+		Signer:          pub,
+	}
+	forged := &protos.Message{Data: data, Hash: []byte{1, 2, 3}}
+
+	d.Dispatch(&protos.GossipMessage{Content: &protos.GossipMessage_Message{Message: forged}})
+	d.Dispatch(&protos.GossipMessage{Content: &protos.GossipMessage_MessageBundle{MessageBundle: &protos.MessageBundle{
+		Hash:     []byte{4, 2},
+		Messages: []*protos.Message{forged, valid},
+	}}})
+
+	assert.Len(t, raw.Messages, 2)
+	assert.Len(t, verified.Messages, 1)
+
+	trimmed := <-verified.Messages
+	assert.Equal(t, []byte{4, 2}, trimmed.GetMessageBundle().GetHash())
+	assert.Equal(t, []*protos.Message{valid}, trimmed.GetMessageBundle().GetMessages())
+	assert.Equal(t, map[string]uint64{"invalid_hash_scheme": 2}, d.Rejections())
+}
+
+// Does a subscriber with a filter only get the matching messages?
+func TestDispatcherFilter(t *testing.T) {
+	ll := *log.NewWithOptions(os.Stderr, log.Options{})
+	d := NewDispatcher(ll)
+
+	casts, err := filter.Parse("type == cast_add and fid == 10626")
+	assert.NoError(t, err)
+	filtered := d.Subscribe("filtered", SubscribeOptions{BufferSize: 4, Backpressure: BackpressureBlock, Filter: casts})
+	everything := d.Subscribe("everything", SubscribeOptions{BufferSize: 4, Backpressure: BackpressureBlock})
+
+	cast := &protos.Message{Data: &protos.MessageData{Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD, Fid: 10626}}
+	other := &protos.Message{Data: &protos.MessageData{Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD, Fid: 3}}
+	like := &protos.Message{Data: &protos.MessageData{Type: protos.MessageType_MESSAGE_TYPE_REACTION_ADD, Fid: 10626}}
+
+	d.Dispatch(&protos.GossipMessage{Content: &protos.GossipMessage_Message{Message: like}})
+	d.Dispatch(&protos.GossipMessage{Content: &protos.GossipMessage_Message{Message: cast}})
+	d.Dispatch(&protos.GossipMessage{Content: &protos.GossipMessage_MessageBundle{MessageBundle: &protos.MessageBundle{
+		Hash:     []byte{4, 2},
+		Messages: []*protos.Message{other, cast, like},
+	}}})
+
+	assert.Len(t, everything.Messages, 3)
+	assert.Len(t, filtered.Messages, 2)
+
+	assert.Equal(t, cast, (<-filtered.Messages).GetMessage())
+	trimmed := <-filtered.Messages
+	assert.Equal(t, []byte{4, 2}, trimmed.GetMessageBundle().GetHash())
+	assert.Equal(t, []*protos.Message{cast}, trimmed.GetMessageBundle().GetMessages())
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// How often the GossipSub scores are copied to the tracker.
+const gossipScoreInspectPeriod = 10 * time.Second
+
+// Every peer starts with this score, so the penalties for sharing an IP with many other peers or misbehaving in the
+// GossipSub protocol can't realistically make it negative: GossipSub prunes & refuses the peers with a negative score.
+// The tracker removes it, so the scores inspected with the AdminService are only the penalties.
+const gossipScoreOffset = 1_000_000
+
+// The scores are only there to be inspected, they must not change who the hub talks to: they stay positive, the
+// thresholds are far below them & no peer is kept in a mesh for its score (Dscore = 0).
+var (
+	gossipScoreParams = &pubsub.PeerScoreParams{
+		AppSpecificScore:            func(p peer.ID) float64 { return gossipScoreOffset },
+		AppSpecificWeight:           1,
+		IPColocationFactorWeight:    -10,
+		IPColocationFactorThreshold: 5,
+		BehaviourPenaltyWeight:      -10,
+		BehaviourPenaltyThreshold:   6,
+		BehaviourPenaltyDecay:       pubsub.ScoreParameterDecay(time.Hour),
+		DecayInterval:               time.Second,
+		DecayToZero:                 0.01,
+		RetainScore:                 time.Hour,
+	}
+	gossipScoreThresholds = &pubsub.PeerScoreThresholds{
+		GossipThreshold:   -4000,
+		PublishThreshold:  -8000,
+		GraylistThreshold: -16000,
+	}
+)
+
+// The parameters of GossipSub, the default ones except for Dscore.
+func gossipSubParams() pubsub.GossipSubParams {
+	params := pubsub.DefaultGossipSubParams()
+	params.Dscore = 0
+	return params
+}
+
+// Follows the topics joined by the hub, the peers of their mesh & the scores of the peers, which GossipSub doesn't
+// expose otherwise.
+type gossipTracker struct {
+	mu     sync.Mutex
+	mesh   map[string]map[peer.ID]bool
+	scores map[peer.ID]float64
+}
+
+func newGossipTracker() *gossipTracker {
+	return &gossipTracker{
+		mesh:   map[string]map[peer.ID]bool{},
+		scores: map[peer.ID]float64{},
+	}
+}
+
+// The options of GossipSub enabling the scores & the tracker.
+func (t *gossipTracker) Options() []pubsub.Option {
+	return []pubsub.Option{
+		pubsub.WithGossipSubParams(gossipSubParams()),
+		pubsub.WithPeerScore(gossipScoreParams, gossipScoreThresholds),
+		pubsub.WithPeerScoreInspect(pubsub.PeerScoreInspectFn(t.updateScores), gossipScoreInspectPeriod),
+		pubsub.WithRawTracer(t),
+	}
+}
+
+func (t *gossipTracker) updateScores(scores map[peer.ID]float64) {
+	penalties := make(map[peer.ID]float64, len(scores))
+	for p, score := range scores {
+		penalties[p] = score - gossipScoreOffset
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.scores = penalties
+}
+
+// The topics joined by the hub, sorted.
+func (t *gossipTracker) Topics() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	topics := []string{}
+	for topic := range t.mesh {
+		topics = append(topics, topic)
+	}
+	slices.Sort(topics)
+	return topics
+}
+
+// The peers of the mesh of the topic, sorted, with their score.
+func (t *gossipTracker) Mesh(topic string) ([]peer.ID, map[peer.ID]float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	peers := []peer.ID{}
+	scores := map[peer.ID]float64{}
+	for p := range t.mesh[topic] {
+		peers = append(peers, p)
+		scores[p] = t.scores[p]
+	}
+	slices.Sort(peers)
+	return peers, scores
+}
+
+func (t *gossipTracker) Join(topic string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.mesh[topic] = map[peer.ID]bool{}
+}
+
+func (t *gossipTracker) Leave(topic string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.mesh, topic)
+}
+
+func (t *gossipTracker) Graft(p peer.ID, topic string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if mesh, ok := t.mesh[topic]; ok {
+		mesh[p] = true
+	}
+}
+
+func (t *gossipTracker) Prune(p peer.ID, topic string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.mesh[topic], p)
+}
+
+func (t *gossipTracker) RemovePeer(p peer.ID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, mesh := range t.mesh {
+		delete(mesh, p)
+	}
+}
+
+// The other events of the pubsub.RawTracer aren't needed.
+func (t *gossipTracker) AddPeer(p peer.ID, proto protocol.ID)             {}
+func (t *gossipTracker) ValidateMessage(msg *pubsub.Message)              {}
+func (t *gossipTracker) DeliverMessage(msg *pubsub.Message)               {}
+func (t *gossipTracker) RejectMessage(msg *pubsub.Message, reason string) {}
+func (t *gossipTracker) DuplicateMessage(msg *pubsub.Message)             {}
+func (t *gossipTracker) ThrottlePeer(p peer.ID)                           {}
+func (t *gossipTracker) RecvRPC(rpc *pubsub.RPC)                          {}
+func (t *gossipTracker) SendRPC(rpc *pubsub.RPC, p peer.ID)               {}
+func (t *gossipTracker) DropRPC(rpc *pubsub.RPC, p peer.ID)               {}
+func (t *gossipTracker) UndeliverableMessage(msg *pubsub.Message)         {}
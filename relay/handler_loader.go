@@ -1,43 +1,62 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"plugin"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/noctisatrae/farseer/config"
+	"github.com/noctisatrae/farseer/filter"
 	"github.com/noctisatrae/farseer/handlers"
-	protos "github.com/noctisatrae/farseer/protos"
-	"github.com/noctisatrae/farseer/utils"
 
 	"github.com/charmbracelet/log"
+	"github.com/pelletier/go-toml/v2"
 )
 
-func LoadHandlersFromConf(conf config.Config, messages chan *protos.GossipMessage, ll log.Logger) error {
+// Every loaded plugin gets its own subscription to the dispatcher so each one of them sees every message. The plugins
+// with a Command run in their own process, the others are loaded from compiled_handlers/<name>.so. The plugins stop
+// when the context is cancelled & wg is done once they all have.
+func LoadHandlersFromConf(ctx context.Context, conf config.Config, dispatcher *Dispatcher, wg *sync.WaitGroup, ll log.Logger) error {
 	keys := conf.GetHandlers()
 
 	availableHandlers, err := ListCompiledHandlers()
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		ll.Error("Couldn't get available handlers from folder!")
 		return err
 	}
 
 	ll.Debug("Available handlers! |", "Handlers", availableHandlers)
 
-	if len(utils.IntersectionOfArrays(keys, availableHandlers)) == 0 {
+	toLoad := []string{}
+	for _, el := range keys {
+		if len(conf.GetDispatchParams(el).Command) > 0 || slices.Contains(availableHandlers, el) {
+			toLoad = append(toLoad, el)
+		} else {
+			ll.Warn("Handler is enabled but has no Command & isn't compiled! |", "Name", el)
+		}
+	}
+
+	if len(toLoad) == 0 {
 		var h handlers.Handler
 		h.InitHandler = func(params map[string]interface{}) error {
 			ll.Debug("Init without plugins")
 			return nil
 		}
-		go h.HandleMessages(messages, ll, nil)
+		sub := dispatcher.Subscribe("default", SubscribeOptions{
+			BufferSize:   conf.Hub.BufferSize,
+			Backpressure: BackpressureBlock,
+		})
+		go h.HandleMessages(sub.Messages, ll, nil)
 	} else {
-		for _, el := range utils.IntersectionOfArrays(keys, availableHandlers) {
+		for _, el := range toLoad {
 			ll.Debug("Loading handlers! |", "Element", el)
 			// am I really reloading the plugins for every message ?
 			// i don't think so: we're not passing individual messages rather the channel
-			err = LoadHandler(el, messages, ll, conf)
+			err = LoadHandler(ctx, el, dispatcher, wg, ll, conf)
 			if err != nil {
 				ll.Error("Couldn't load handlers from conf! |", "Error", err)
 				return err
@@ -48,27 +67,85 @@ func LoadHandlersFromConf(conf config.Config, messages chan *protos.GossipMessag
 	return nil
 }
 
-func LoadHandler(name string, messages chan *protos.GossipMessage, ll log.Logger, conf config.Config) error {
-	pl, err := plugin.Open(fmt.Sprintf("compiled_handlers/%s.so", name))
+func LoadHandler(ctx context.Context, name string, dispatcher *Dispatcher, wg *sync.WaitGroup, ll log.Logger, conf config.Config) error {
+	dispatchParams := conf.GetDispatchParams(name)
+	policy, err := ParseBackpressure(dispatchParams.Backpressure)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid configuration for handler %s: %w", name, err)
+	}
+	verifiedOnly, err := ParseValidation(dispatchParams.Validation)
+	if err != nil {
+		return fmt.Errorf("invalid configuration for handler %s: %w", name, err)
+	}
+	if keys := conf.DeprecatedKeys(name); len(keys) > 0 {
+		ll.Warn("Deprecated keys were turned into a Filter, set it instead! |", "Name", name, "Keys", keys, "Filter", dispatchParams.Filter)
+	}
+	messageFilter, err := filter.Parse(dispatchParams.Filter)
+	if err != nil {
+		return fmt.Errorf("invalid configuration for handler %s: %w", name, err)
+	}
+	opts := SubscribeOptions{
+		BufferSize:   dispatchParams.BufferSize,
+		Backpressure: policy,
+		VerifiedOnly: verifiedOnly,
+		Filter:       messageFilter,
 	}
 
-	ll.Debug("Opening shared lib! |", "Name", name, "Handlers", conf.GetHandlers())
+	pluginConfig, err := toml.Marshal(conf.GetParams(name))
+	if err != nil {
+		return fmt.Errorf("couldn't encode the configuration of handler %s: %w", name, err)
+	}
+
+	if len(dispatchParams.Command) > 0 {
+		ll.Debug("Launching plugin! |", "Name", name, "Command", dispatchParams.Command)
+		sub := dispatcher.Subscribe(name, opts)
+		process := NewPluginProcess(name, dispatchParams.Command, pluginConfig, sub, ll)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := process.Run(ctx); err != nil {
+				ll.Error("A handler encountered a problem, it won't receive messages! |", "Name", name, "Error", err)
+				dispatcher.Unsubscribe(sub)
+			}
+		}()
+		return nil
+	}
 
-	plEventHandlersSymbol, err := pl.Lookup("PluginHandler")
+	pl, err := plugin.Open(fmt.Sprintf("compiled_handlers/%s.so", name))
 	if err != nil {
-		log.Error("Couldn't find the symbol containing the event handlers!", "PluginName", name)
 		return err
 	}
 
-	plEventHandlers := *plEventHandlersSymbol.(*handlers.Handler)
+	ll.Debug("Opening shared lib! |", "Name", name, "Handlers", conf.GetHandlers())
 
-	params := conf.GetParams(name)
-	if params == nil {
-		params = map[string]interface{}{}
+	// plugins using the v2 API export Plugin, the others PluginHandler
+	var plHandler handlers.Plugin
+	if plSymbol, err := pl.Lookup("Plugin"); err == nil {
+		plHandler = *plSymbol.(*handlers.Plugin)
+	} else {
+		plEventHandlersSymbol, err := pl.Lookup("PluginHandler")
+		if err != nil {
+			log.Error("Couldn't find the symbol containing the event handlers!", "PluginName", name)
+			return err
+		}
+		plHandler = handlers.FromHandler(*plEventHandlersSymbol.(*handlers.Handler))
 	}
-	go plEventHandlers.HandleMessages(messages, ll, params)
+
+	plHandler = instrumentedPlugin{Plugin: plHandler, name: name}
+	loadedPlugins.Starting(name, pluginKindSharedLibrary)
+
+	sub := dispatcher.Subscribe(name, opts)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := handlers.RunPlugin(ctx, plHandler, pluginConfig, sub.Messages, ll)
+		if err != nil {
+			// the plugin couldn't be configured when its Init wasn't called
+			loadedPlugins.Initialized(name, err)
+			ll.Error("A handler encountered a problem, it won't receive messages! |", "Name", name, "Error", err)
+			dispatcher.Unsubscribe(sub)
+		}
+	}()
 
 	return nil
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The state of one of the checks of /healthz & /readyz.
+type HealthCheck struct {
+	Ok     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+type HealthReport struct {
+	// "ok" or "unavailable"
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+// Tells if the relay is alive & ready to handle the messages of the network.
+type Health struct {
+	// How many peers the host is connected to.
+	Peers func() int
+	// primary, contact_info & peer_discovery, the first one being primary.
+	Networks []*Network
+	// Ready once the host is connected to this many peers.
+	MinPeers uint
+	// Ready while a message of the primary topic was received in this window.
+	MessageWindow time.Duration
+
+	plugins *pluginRegistry
+}
+
+func NewHealth(peers func() int, networks []*Network, minPeers uint, messageWindow time.Duration) *Health {
+	return &Health{
+		Peers:         peers,
+		Networks:      networks,
+		MinPeers:      minPeers,
+		MessageWindow: messageWindow,
+		plugins:       loadedPlugins,
+	}
+}
+
+// Alive while the relay still receives the messages of its gossip subscriptions: a restart is the only way to get
+// them back.
+func (health *Health) Live() HealthReport {
+	return newHealthReport(map[string]HealthCheck{
+		"subscriptions": health.checkSubscriptions(),
+	})
+}
+
+// Ready when the relay is alive, connected to enough peers, every plugin started & the network is sending messages.
+func (health *Health) Ready() HealthReport {
+	return newHealthReport(map[string]HealthCheck{
+		"subscriptions":  health.checkSubscriptions(),
+		"peers":          health.checkPeers(),
+		"plugins":        health.checkPlugins(),
+		"primaryMessage": health.checkPrimaryMessage(),
+	})
+}
+
+func newHealthReport(checks map[string]HealthCheck) HealthReport {
+	report := HealthReport{Status: "ok", Checks: checks}
+	for _, check := range checks {
+		if !check.Ok {
+			report.Status = "unavailable"
+		}
+	}
+	return report
+}
+
+func (health *Health) checkSubscriptions() HealthCheck {
+	inactive := []string{}
+	for _, netw := range health.Networks {
+		if !netw.active.Load() {
+			inactive = append(inactive, netw.name)
+		}
+	}
+	if len(inactive) > 0 {
+		return HealthCheck{Ok: false, Detail: fmt.Sprintf("not subscribed to %s", strings.Join(inactive, ", "))}
+	}
+	return HealthCheck{Ok: true, Detail: fmt.Sprintf("subscribed to %d topics", len(health.Networks))}
+}
+
+func (health *Health) checkPeers() HealthCheck {
+	peers := health.Peers()
+	return HealthCheck{
+		Ok:     peers >= int(health.MinPeers),
+		Detail: fmt.Sprintf("%d connected, %d required", peers, health.MinPeers),
+	}
+}
+
+func (health *Health) checkPlugins() HealthCheck {
+	if problems := health.plugins.notReady(); len(problems) > 0 {
+		return HealthCheck{Ok: false, Detail: strings.Join(problems, "; ")}
+	}
+	return HealthCheck{Ok: true, Detail: fmt.Sprintf("%d started", health.plugins.count())}
+}
+
+func (health *Health) checkPrimaryMessage() HealthCheck {
+	if len(health.Networks) == 0 {
+		return HealthCheck{Ok: false, Detail: "not subscribed to primary"}
+	}
+	lastMessage := health.Networks[0].lastMessage.Load()
+	if lastMessage == 0 {
+		return HealthCheck{Ok: false, Detail: "no message received yet"}
+	}
+	since := time.Since(time.Unix(0, lastMessage)).Truncate(time.Millisecond)
+	return HealthCheck{
+		Ok:     since <= health.MessageWindow,
+		Detail: fmt.Sprintf("last message received %s ago, %s allowed", since, health.MessageWindow),
+	}
+}
+
+// Remembers that a message was received on the topic.
+func (netw *Network) received() {
+	netw.lastMessage.Store(time.Now().UnixNano())
+}
+
+// Answers with the report as JSON, 200 when it's ok & 503 otherwise.
+func serveHealthReport(report func() HealthReport) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rep := report()
+		w.Header().Set("Content-Type", "application/json")
+		if rep.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(rep)
+	}
+}
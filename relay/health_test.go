@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHealth(peers int) (*Health, []*Network) {
+	networks := []*Network{{name: "primary"}, {name: "contact_info"}, {name: "peer_discovery"}}
+	for _, netw := range networks {
+		netw.active.Store(true)
+	}
+	health := NewHealth(func() int { return peers }, networks, 2, time.Minute)
+	health.plugins = newPluginRegistry()
+	return health, networks
+}
+
+// Is the relay only ready once every check is?
+func TestReady(t *testing.T) {
+	health, networks := newTestHealth(3)
+	health.plugins.Starting("postgresql", pluginKindSharedLibrary)
+
+	report := health.Ready()
+	assert.Equal(t, "unavailable", report.Status)
+	assert.True(t, report.Checks["subscriptions"].Ok)
+	assert.True(t, report.Checks["peers"].Ok)
+	assert.Equal(t, HealthCheck{Ok: false, Detail: "postgresql is starting"}, report.Checks["plugins"])
+	assert.Equal(t, HealthCheck{Ok: false, Detail: "no message received yet"}, report.Checks["primaryMessage"])
+
+	health.plugins.Initialized("postgresql", nil)
+	networks[0].received()
+	report = health.Ready()
+	assert.Equal(t, "ok", report.Status)
+	assert.Equal(t, "1 started", report.Checks["plugins"].Detail)
+
+	// a message older than the window
+	networks[0].lastMessage.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	assert.False(t, health.Ready().Checks["primaryMessage"].Ok)
+	networks[0].received()
+
+	health.plugins.Initialized("redis", errors.New("connection refused"))
+	assert.Equal(t, HealthCheck{Ok: false, Detail: "redis failed: connection refused"}, health.Ready().Checks["plugins"])
+}
+
+// Are too few peers & a closed subscription reported?
+func TestNotReady(t *testing.T) {
+	health, networks := newTestHealth(1)
+	networks[0].received()
+	networks[2].active.Store(false)
+
+	report := health.Ready()
+	assert.Equal(t, HealthCheck{Ok: false, Detail: "1 connected, 2 required"}, report.Checks["peers"])
+	assert.Equal(t, HealthCheck{Ok: false, Detail: "not subscribed to peer_discovery"}, report.Checks["subscriptions"])
+	assert.Equal(t, "unavailable", health.Live().Status)
+}
+
+// Do the endpoints answer with the checks as JSON & a status code docker understands?
+func TestServeHealthReport(t *testing.T) {
+	health, networks := newTestHealth(0)
+	networks[0].received()
+
+	rec := httptest.NewRecorder()
+	serveHealthReport(health.Live)(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	rec = httptest.NewRecorder()
+	serveHealthReport(health.Ready)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var report HealthReport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, "unavailable", report.Status)
+	assert.Len(t, report.Checks, 4)
+	assert.False(t, report.Checks["peers"].Ok)
+}
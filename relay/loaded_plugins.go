@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	protos "github.com/noctisatrae/farseer/protos"
+)
+
+const (
+	pluginKindProcess       = "process"
+	pluginKindSharedLibrary = "shared_library"
+)
+
+type handlerCounters struct {
+	handled uint64
+	errors  uint64
+}
+
+// What the relay knows about a loaded plugin.
+type pluginState struct {
+	kind string
+	// did the InitHandler return? err is what it returned.
+	initialized bool
+	err         error
+	handlers    map[protos.MessageType]*handlerCounters
+}
+
+// The plugins loaded by the relay, whether they started & what their handlers did. The relay isn't ready while one of
+// them failed to start, and the AdminService reports them.
+type pluginRegistry struct {
+	mu      sync.Mutex
+	plugins map[string]*pluginState
+}
+
+var loadedPlugins = newPluginRegistry()
+
+func newPluginRegistry() *pluginRegistry {
+	return &pluginRegistry{plugins: map[string]*pluginState{}}
+}
+
+func (r *pluginRegistry) get(name string) *pluginState {
+	state, ok := r.plugins[name]
+	if !ok {
+		state = &pluginState{handlers: map[protos.MessageType]*handlerCounters{}}
+		r.plugins[name] = state
+	}
+	return state
+}
+
+// The plugin is loaded or restarted & hasn't run its InitHandler yet. Its counters are kept across restarts.
+func (r *pluginRegistry) Starting(name string, kind string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := r.get(name)
+	state.kind = kind
+	state.initialized = false
+	state.err = nil
+}
+
+// The InitHandler of the plugin returned, err is nil when it succeeded.
+func (r *pluginRegistry) Initialized(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := r.get(name)
+	state.initialized = true
+	state.err = err
+}
+
+// The handler of the plugin for this type of message was called.
+func (r *pluginRegistry) Handled(name string, msgType protos.MessageType, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := r.get(name)
+	counters, ok := state.handlers[msgType]
+	if !ok {
+		counters = &handlerCounters{}
+		state.handlers[msgType] = counters
+	}
+	counters.handled++
+	if err != nil {
+		counters.errors++
+	}
+}
+
+// The plugins whose InitHandler failed or hasn't returned yet, with why.
+func (r *pluginRegistry) notReady() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	problems := []string{}
+	for name, state := range r.plugins {
+		if !state.initialized {
+			problems = append(problems, fmt.Sprintf("%s is starting", name))
+		} else if state.err != nil {
+			problems = append(problems, fmt.Sprintf("%s failed: %v", name, state.err))
+		}
+	}
+	slices.Sort(problems)
+	return problems
+}
+
+func (r *pluginRegistry) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.plugins)
+}
+
+// The plugins sorted by name, with their handlers sorted by type of message.
+func (r *pluginRegistry) List() []*protos.LoadedPlugin {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plugins := []*protos.LoadedPlugin{}
+	for name, state := range r.plugins {
+		plugin := &protos.LoadedPlugin{
+			Name:        name,
+			Kind:        state.kind,
+			Initialized: state.initialized && state.err == nil,
+		}
+		if state.err != nil {
+			plugin.InitError = state.err.Error()
+		}
+		for msgType, counters := range state.handlers {
+			plugin.Handlers = append(plugin.Handlers, &protos.HandlerCounters{
+				MessageType: msgType,
+				Handled:     counters.handled,
+				Errors:      counters.errors,
+			})
+		}
+		slices.SortFunc(plugin.Handlers, func(a, b *protos.HandlerCounters) int {
+			return int(a.MessageType) - int(b.MessageType)
+		})
+		plugins = append(plugins, plugin)
+	}
+	slices.SortFunc(plugins, func(a, b *protos.LoadedPlugin) int {
+		if a.Name < b.Name {
+			return -1
+		} else if a.Name > b.Name {
+			return 1
+		}
+		return 0
+	})
+	return plugins
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/noctisatrae/farseer/config"
 	protos "github.com/noctisatrae/farseer/protos"
+	"github.com/noctisatrae/farseer/store"
 
 	"github.com/charmbracelet/log"
 
@@ -32,6 +33,9 @@ import (
 
 const HUB_VERSION = "2024.7.24"
 
+// The version of Hubble announced in the contact info.
+const APP_VERSION = "1.9.2"
+
 type ResolveResult struct {
 	ResolvedMultiaddrs []multiaddr.Multiaddr
 	Error              error
@@ -86,6 +90,13 @@ func getId() (crypto.PrivKey, error) {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := replay(os.Args[2:]); err != nil {
+			log.Fatal("Couldn't replay the capture! |", "Error", err)
+		}
+		return
+	}
+
 	conf, err := config.Load("config.toml")
 	if err != nil {
 		log.Error("Couldn't parse config file! |", "Error", err)
@@ -172,7 +183,8 @@ func main() {
 
 	// params := pubsub.WithGossipSubParams(psParams)
 
-	ps, err := pubsub.NewGossipSub(ctx, h)
+	tracker := newGossipTracker()
+	ps, err := pubsub.NewGossipSub(ctx, h, tracker.Options()...)
 	if err != nil {
 		log.Error(err)
 	}
@@ -192,46 +204,86 @@ func main() {
 		log.Fatal(err.Error())
 	}
 
-	// START THE RPC SERVER
+	// OPEN THE MESSAGE STORE
+	msgStore, err := store.Open(conf.Hub.StorePath)
+	if err != nil {
+		log.Fatal("Couldn't open the message store! |", "Error", err, "Path", conf.Hub.StorePath)
+	}
+
+	// HANDLE THE MESSAGES
+	storeBackpressure, err := ParseBackpressure(conf.Hub.StoreBackpressure)
+	if err != nil {
+		log.Fatal("Invalid StoreBackpressure! |", "Error", err)
+	}
+	dispatcher := NewDispatcher(netwPrimary.logger)
+	storeSub := dispatcher.Subscribe("store", SubscribeOptions{
+		BufferSize:   conf.Hub.BufferSize,
+		Backpressure: storeBackpressure,
+		VerifiedOnly: true,
+	})
+	go msgStore.MergeMessages(storeSub.Messages, netwPrimary.logger)
+
 	var wg sync.WaitGroup
+	pluginCtx, stopPlugins := context.WithCancel(ctx)
+	err = LoadHandlersFromConf(pluginCtx, conf, dispatcher, &wg, netwPrimary.logger)
+	if err != nil {
+		log.Error("Couldn't load the handlers! |", "Error", err)
+	}
+	go dispatcher.Run(netwPrimary.NetworkMessage)
+
+	// START THE RPC SERVER
 	stopCh := make(chan struct{})
 
 	wg.Add(1)
-	go Start(&wg, stopCh, *netwPrimary)
+	go Start(&wg, stopCh, netwPrimary, msgStore, dispatcher)
 
-	// HANDLE THE MESSAGES
-	LoadHandlersFromConf(conf, netwPrimary.NetworkMessage, netwPrimary.logger)
-	go HandleContactInfo(netwContact.NetworkMessage, netwContact.logger, h, ctx)
+	// START THE ADMIN SERVICE
+	adminLogger := *log.WithPrefix("admin")
+	wg.Add(1)
+	go StartAdmin(&wg, stopCh, conf.Hub.AdminAddress, newAdminServer(h, ps, tracker, adminLogger), adminLogger)
+
+	// SERVE THE METRICS & THE HEALTH CHECKS
+	registerGaugeFunc("farseer_connected_peers", "Peers the hub is connected to.", nil, func() float64 {
+		return float64(len(h.Network().Peers()))
+	})
+	health := NewHealth(func() int { return len(h.Network().Peers()) }, []*Network{netwPrimary, netwContact, netwDiscovery},
+		conf.Hub.ReadyMinPeers, time.Duration(conf.Hub.ReadyMessageWindow)*time.Second)
+	wg.Add(1)
+	go ServeMetrics(&wg, stopCh, conf.Hub.MetricsPort, health, *log.WithPrefix("metrics"))
+
+	minHubVersion := conf.Hub.MinHubVersion
+	if minHubVersion == "" {
+		minHubVersion = HUB_VERSION
+	}
+	peerBook := NewPeerBook(ctx, h, PeerBookOptions{
+		Network:       protos.FarcasterNetwork(conf.Hub.Network),
+		MinHubVersion: minHubVersion,
+		MaxOutbound:   int(conf.Hub.MaxOutboundPeers),
+		MaxPeers:      peerBookSize,
+		ContactTTL:    peerContactTTL,
+	}, netwContact.logger)
+	go HandleContactInfo(netwContact.NetworkMessage, netwContact.logger, peerBook)
 	go logMessages(netwDiscovery.NetworkMessage, netwDiscovery.logger)
 
 	// SEND CONTACT_INFO
+	if _, err := NewContactInfo(conf.Hub, privKey, time.Now()); err != nil {
+		log.Fatal("Can't announce the hub with this config! |", "Error", err)
+	}
+	if isLoopbackHost(conf.Hub.RpcHost) {
+		log.Warn("The gRPC API isn't announced to the other hubs, it only listens on a loopback address! |", "RpcHost", conf.Hub.RpcHost)
+	}
 	go func() {
 		ticker := time.NewTicker(time.Duration(conf.Hub.ContactInterval) * time.Second)
 		defer ticker.Stop()
 
 		for {
 			<-ticker.C
-			netwContact.PublishContactInfo(&protos.ContactInfoContent{
-				HubVersion: HUB_VERSION,
-				Network:    1,
-				GossipAddress: &protos.GossipAddressInfo{
-					Family:  4, // to know if address ip4/ip6?
-					Address: conf.Hub.PublicHubIp,
-					Port:    uint32(conf.Hub.GossipPort),
-				},
-				Body: &protos.ContactInfoContentBody{
-					GossipAddress: &protos.GossipAddressInfo{
-						Family:  4,
-						Address: conf.Hub.PublicHubIp,
-						Port:    uint32(conf.Hub.GossipPort),
-					},
-					HubVersion: HUB_VERSION,
-					Network:    1,
-					Timestamp:  uint64(time.Now().Unix()),
-					AppVersion: "1.9.2",
-				},
-				Timestamp: uint64(time.Now().Unix()),
-			})
+			contactInfo, err := NewContactInfo(conf.Hub, privKey, time.Now())
+			if err != nil {
+				netwContact.logger.Error("Couldn't create the contact info! |", "Error", err)
+				continue
+			}
+			netwContact.PublishContactInfo(contactInfo)
 		}
 	}()
 
@@ -247,6 +299,7 @@ func main() {
 	log.Info("Received signal, shutting down...")
 
 	close(stopCh)
+	stopPlugins()
 
 	// shut the node down
 	if err := h.Close(); err != nil {
@@ -254,4 +307,8 @@ func main() {
 	}
 
 	wg.Wait()
+
+	if err := msgStore.Close(); err != nil {
+		log.Error("Couldn't close the message store! |", "Error", err)
+	}
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/noctisatrae/farseer/handlers"
+	protos "github.com/noctisatrae/farseer/protos"
+	"github.com/noctisatrae/farseer/validation"
+
+	"github.com/charmbracelet/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// The metrics of the relay, served on /metrics with the ones of the Go runtime & of the process.
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	gossipMessagesReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "farseer_gossip_messages_received_total",
+		Help: "Gossip messages received, by topic.",
+	}, []string{"topic"})
+	messagesReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "farseer_messages_received_total",
+		Help: "Farcaster messages received on gossip, alone or in a bundle, by type.",
+	}, []string{"type"})
+	gossipDecodeFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "farseer_gossip_decode_failures_total",
+		Help: "Gossip messages that couldn't be decoded, by topic.",
+	}, []string{"topic"})
+	pluginHandleDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "farseer_plugin_handle_duration_seconds",
+		Help:    "How long the plugins take to handle a message, by plugin.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"plugin"})
+	pluginHandleErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "farseer_plugin_handle_errors_total",
+		Help: "Messages the plugins returned an error for, by plugin.",
+	}, []string{"plugin"})
+	submitMessageOutcomes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "farseer_submit_message_total",
+		Help: "Messages submitted with the SubmitMessage RPC, by outcome: published, invalid or publish_failed.",
+	}, []string{"outcome"})
+	subscriberDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "farseer_subscriber_dropped_messages_total",
+		Help: "Messages dropped because a subscriber of the dispatcher (the store, a plugin...) couldn't keep up, by subscriber.",
+	}, []string{"subscriber"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		gossipMessagesReceived,
+		messagesReceived,
+		gossipDecodeFailures,
+		pluginHandleDuration,
+		pluginHandleErrors,
+		submitMessageOutcomes,
+		subscriberDropped,
+	)
+}
+
+// Exposes a value read when the metrics are scraped, like the number of messages waiting in a channel. A gauge
+// already registered with the same labels is replaced.
+func registerGaugeFunc(name string, help string, labels prometheus.Labels, value func() float64) {
+	gauge := prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help, ConstLabels: labels}, value)
+	if err := metricsRegistry.Register(gauge); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			metricsRegistry.Unregister(already.ExistingCollector)
+			metricsRegistry.MustRegister(gauge)
+			return
+		}
+		log.Error("Couldn't register a metric! |", "Name", name, "Error", err)
+	}
+}
+
+// Records how long a plugin took to handle a message & whether it failed.
+func observePluginCall(plugin string, msgType protos.MessageType, startedAt time.Time, err error) {
+	loadedPlugins.Handled(plugin, msgType, err)
+	pluginHandleDuration.WithLabelValues(plugin).Observe(time.Since(startedAt).Seconds())
+	if err != nil {
+		pluginHandleErrors.WithLabelValues(plugin).Inc()
+	}
+}
+
+// Counts the messages of a gossip message by type. The ones only sent with their data_bytes are decoded, like the
+// dispatcher would.
+func countMessages(msgB *protos.GossipMessage) {
+	msgs, _ := handlers.ExtractMessages(msgB)
+	for _, m := range msgs {
+		validation.DecodeData(m)
+		messagesReceived.WithLabelValues(m.GetData().GetType().String()).Inc()
+	}
+}
+
+// Measures the plugins loaded from compiled_handlers/<name>.so, the ones in their own process are measured by
+// PluginProcess.
+type instrumentedPlugin struct {
+	handlers.Plugin
+	name string
+}
+
+func (p instrumentedPlugin) Init(ctx context.Context) error {
+	err := p.Plugin.Init(ctx)
+	loadedPlugins.Initialized(p.name, err)
+	return err
+}
+
+func (p instrumentedPlugin) HandleMessage(ctx context.Context, m *protos.Message, meta handlers.MessageMeta) error {
+	startedAt := time.Now()
+	err := p.Plugin.HandleMessage(ctx, m, meta)
+	observePluginCall(p.name, m.GetData().GetType(), startedAt, err)
+	return err
+}
+
+// Serves the metrics on /metrics, and /healthz & /readyz when health isn't nil, until stopCh is closed.
+func ServeMetrics(wg *sync.WaitGroup, stopCh <-chan struct{}, port uint, health *Health, ll log.Logger) {
+	defer wg.Done()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{Registry: metricsRegistry}))
+	if health != nil {
+		mux.Handle("/healthz", serveHealthReport(health.Live))
+		mux.Handle("/readyz", serveHealthReport(health.Ready))
+	}
+	server := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: mux}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			ll.Error("Couldn't serve the metrics! |", "Port", port, "Error", err)
+		}
+	}()
+	ll.Info("Serving the metrics! |", "Port", port)
+
+	<-stopCh
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		ll.Error("Couldn't stop the metrics server! |", "Error", err)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/noctisatrae/farseer/handlers"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingPlugin struct {
+	handlers.Plugin
+	fail bool
+}
+
+func (p failingPlugin) HandleMessage(ctx context.Context, m *protos.Message, meta handlers.MessageMeta) error {
+	if p.fail {
+		return errors.New("can't handle it")
+	}
+	return nil
+}
+
+// Are the calls & the errors of the plugins counted?
+func TestInstrumentedPlugin(t *testing.T) {
+	ok := instrumentedPlugin{Plugin: failingPlugin{}, name: "metrics_ok"}
+	failing := instrumentedPlugin{Plugin: failingPlugin{fail: true}, name: "metrics_failing"}
+
+	assert.NoError(t, ok.HandleMessage(context.Background(), &protos.Message{}, handlers.MessageMeta{}))
+	assert.NoError(t, ok.HandleMessage(context.Background(), &protos.Message{}, handlers.MessageMeta{}))
+	assert.Error(t, failing.HandleMessage(context.Background(), &protos.Message{}, handlers.MessageMeta{}))
+
+	assert.Equal(t, 0.0, testutil.ToFloat64(pluginHandleErrors.WithLabelValues("metrics_ok")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(pluginHandleErrors.WithLabelValues("metrics_failing")))
+	assert.Equal(t, 2, testutil.CollectAndCount(pluginHandleDuration, "farseer_plugin_handle_duration_seconds"))
+}
+
+// Are the messages of a bundle counted by type?
+func TestCountMessages(t *testing.T) {
+	before := testutil.ToFloat64(messagesReceived.WithLabelValues("MESSAGE_TYPE_LINK_ADD"))
+
+	link := &protos.Message{Data: &protos.MessageData{Type: protos.MessageType_MESSAGE_TYPE_LINK_ADD}}
+	countMessages(&protos.GossipMessage{Content: &protos.GossipMessage_MessageBundle{
+		MessageBundle: &protos.MessageBundle{Messages: []*protos.Message{link, link}},
+	}})
+
+	assert.Equal(t, before+2, testutil.ToFloat64(messagesReceived.WithLabelValues("MESSAGE_TYPE_LINK_ADD")))
+}
+
+// Are the metrics served until the relay stops?
+func TestServeMetrics(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	port := uint(listener.Addr().(*net.TCPAddr).Port)
+	listener.Close()
+
+	registerGaugeFunc("farseer_test_gauge", "A gauge of the tests.", nil, func() float64 { return 1 })
+	registerGaugeFunc("farseer_test_gauge", "A gauge of the tests.", nil, func() float64 { return 42 })
+
+	var wg sync.WaitGroup
+	stopCh := make(chan struct{})
+	wg.Add(1)
+	go ServeMetrics(&wg, stopCh, port, nil, *log.Default())
+
+	var body string
+	assert.Eventually(t, func() bool {
+		res, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/metrics", port))
+		if err != nil {
+			return false
+		}
+		defer res.Body.Close()
+		b, _ := io.ReadAll(res.Body)
+		body = string(b)
+		return res.StatusCode == http.StatusOK
+	}, 5*time.Second, 50*time.Millisecond)
+
+	assert.True(t, strings.Contains(body, "farseer_test_gauge 42"))
+	assert.True(t, strings.Contains(body, "go_goroutines"))
+
+	close(stopCh)
+	wg.Wait()
+}
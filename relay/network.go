@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync/atomic"
 
 	"github.com/noctisatrae/farseer/config"
 	protos "github.com/noctisatrae/farseer/protos"
 	"github.com/noctisatrae/farseer/time"
+	"github.com/noctisatrae/farseer/validation"
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/charmbracelet/log"
@@ -18,6 +21,12 @@ import (
 
 type Network struct {
 	NetworkMessage chan *protos.GossipMessage
+	// primary, contact_info or peer_discovery
+	name string
+	// is the subscription still delivering messages?
+	active atomic.Bool
+	// when the last message was received, in Unix nanoseconds
+	lastMessage atomic.Int64
 
 	ctx   context.Context
 	ps    *pubsub.PubSub
@@ -62,14 +71,20 @@ func (netw *Network) Publish(m *protos.GossipMessage) error {
 	mEncoded, err := proto.Marshal(m)
 	if err != nil {
 		netw.logger.Error("Couldn't encode the gossip message! |", "Error", err)
+		return err
 	}
 
 	err = netw.topic.Publish(netw.ctx, mEncoded)
 	return err
 }
 
+// The gossip topic of a Farcaster network, named like Hubble does: f_network_<network>_<name>.
+func topicName(network uint, name string) string {
+	return fmt.Sprintf("f_network_%d_%s", network, name)
+}
+
 func ReceiveMessages(ctx context.Context, ps *pubsub.PubSub, selfId peer.ID, topicReq string, conf config.Config) (*Network, error) {
-	req := fmt.Sprint("f_network_1_", topicReq)
+	req := topicName(conf.Hub.Network, topicReq)
 	log.Info("Suscribing to a new topic! |", "Topic", req)
 
 	topic, err := ps.Join(req)
@@ -91,6 +106,7 @@ func ReceiveMessages(ctx context.Context, ps *pubsub.PubSub, selfId peer.ID, top
 	}
 
 	netw := &Network{
+		name:           topicReq,
 		ctx:            ctx,
 		ps:             ps,
 		topic:          topic,
@@ -99,6 +115,10 @@ func ReceiveMessages(ctx context.Context, ps *pubsub.PubSub, selfId peer.ID, top
 		self:           selfId,
 		logger:         *ll,
 	}
+	netw.active.Store(true)
+
+	registerGaugeFunc("farseer_network_channel_depth", "Messages received on the topic & waiting to be handled.",
+		prometheus.Labels{"topic": topicReq}, func() float64 { return float64(len(netw.NetworkMessage)) })
 
 	go netw.readLoop()
 	return netw, nil
@@ -109,6 +129,7 @@ func (netw *Network) readLoop() {
 		msg, err := netw.sub.Next(netw.ctx)
 		if err != nil {
 			log.Error(err.Error())
+			netw.active.Store(false)
 			close(netw.NetworkMessage)
 			return
 		}
@@ -121,9 +142,21 @@ func (netw *Network) readLoop() {
 		netwMsg := new(protos.GossipMessage)
 		err = proto.Unmarshal(msg.Data, netwMsg)
 		if err != nil {
+			gossipDecodeFailures.WithLabelValues(netw.name).Inc()
 			log.Error("Could not parse the incoming message! |", "error", err)
 			continue
 		}
+
+		// keep the original serialization of the messages so their hash can be checked
+		err = validation.AttachDataBytes(msg.Data, netwMsg)
+		if err != nil {
+			netw.logger.Debug("Couldn't read the data bytes of the incoming message! |", "error", err)
+		}
+
+		netw.received()
+		gossipMessagesReceived.WithLabelValues(netw.name).Inc()
+		countMessages(netwMsg)
+
 		netw.NetworkMessage <- netwMsg
 	}
 }
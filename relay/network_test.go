@@ -0,0 +1,13 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Are the topics of the network the hub is part of joined?
+func TestTopicName(t *testing.T) {
+	assert.Equal(t, "f_network_1_primary", topicName(1, "primary"))
+	assert.Equal(t, "f_network_2_contact_info", topicName(2, "contact_info"))
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+const (
+	// How long the peer book waits for a connection.
+	peerDialTimeout = 10 * time.Second
+	// Delays before dialing again a peer that couldn't be reached, doubled after every failure.
+	peerMinBackoff = 30 * time.Second
+	peerMaxBackoff = 30 * time.Minute
+	// The peers the hub remembers at most & how long it remembers a peer that stopped sending contact infos.
+	peerBookSize   = 1000
+	peerContactTTL = 6 * time.Hour
+)
+
+type PeerBookOptions struct {
+	// The contact infos of the other networks are rejected.
+	Network protos.FarcasterNetwork
+	// The contact infos of the hubs running an older version of the protocol are rejected.
+	MinHubVersion string
+	// The peer book stops dialing once the hub has this many outbound connections.
+	MaxOutbound int
+	// The peers whose contact info was received the longest ago are forgotten past this many.
+	MaxPeers int
+	// A peer is forgotten when its latest contact info was received longer ago than this.
+	ContactTTL time.Duration
+}
+
+// The latest contact info received from a peer.
+type PeerContact struct {
+	PeerId peer.ID
+	Body   *protos.ContactInfoContentBody
+	// When the peer created the contact info, from its body.
+	Timestamp  uint64
+	ReceivedAt time.Time
+}
+
+type peerEntry struct {
+	contact PeerContact
+	dialing bool
+	// failed dials since the last successful one
+	failures int
+	nextDial time.Time
+}
+
+// Remembers the peers announced on the contact_info topic & dials them until the hub has enough outbound connections.
+// Only the contact infos signed by the peer that sent them, from the same network & a recent enough version of the
+// protocol, are kept.
+type PeerBook struct {
+	ctx  context.Context
+	host host.Host
+	opts PeerBookOptions
+	ll   log.Logger
+
+	mu    sync.Mutex
+	peers map[peer.ID]*peerEntry
+	// what time it is, replaced by the tests
+	now func() time.Time
+}
+
+func NewPeerBook(ctx context.Context, h host.Host, opts PeerBookOptions, ll log.Logger) *PeerBook {
+	return &PeerBook{
+		ctx:   ctx,
+		host:  h,
+		opts:  opts,
+		ll:    ll,
+		peers: map[peer.ID]*peerEntry{},
+		now:   time.Now,
+	}
+}
+
+// Verifies the contact info & keeps it when it's newer than the one known for the peer, then dials the peer if the
+// hub isn't connected to it yet. The stale peers are forgotten, then the oldest ones while there are too many.
+func (b *PeerBook) Add(m *protos.GossipMessage) (PeerContact, error) {
+	peerId, body, err := verifyContactInfo(m)
+	if err != nil {
+		return PeerContact{}, err
+	}
+	if body.Network != b.opts.Network {
+		return PeerContact{}, fmt.Errorf("%s is part of %s", peerId, body.Network)
+	}
+	cmp, err := compareHubVersions(body.HubVersion, b.opts.MinHubVersion)
+	if err != nil {
+		return PeerContact{}, fmt.Errorf("%s announced an %w", peerId, err)
+	}
+	if cmp < 0 {
+		return PeerContact{}, fmt.Errorf("%s runs version %s, older than %s", peerId, body.HubVersion, b.opts.MinHubVersion)
+	}
+
+	contact := PeerContact{
+		PeerId:     peerId,
+		Body:       body,
+		Timestamp:  body.Timestamp,
+		ReceivedAt: b.now(),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, ok := b.peers[peerId]
+	if ok && contact.Timestamp <= entry.contact.Timestamp {
+		return PeerContact{}, fmt.Errorf("%s already sent a newer contact info", peerId)
+	}
+	if !ok {
+		entry = &peerEntry{}
+		b.peers[peerId] = entry
+	}
+	entry.contact = contact
+	b.prune()
+	if b.peers[peerId] != entry {
+		return PeerContact{}, fmt.Errorf("%s was forgotten right away, the peer book is full", peerId)
+	}
+
+	b.maybeDial(entry)
+	return contact, nil
+}
+
+// Forgets the peers past their TTL, then the ones received the longest ago until there are MaxPeers left. The peers
+// being dialed are kept until the dial ends, so they're still counted as outbound connections. Must be called with the
+// lock held.
+func (b *PeerBook) prune() {
+	now := b.now()
+	for id, entry := range b.peers {
+		if !entry.dialing && now.Sub(entry.contact.ReceivedAt) > b.opts.ContactTTL {
+			b.ll.Debug("Forgetting a peer that stopped sending contact infos! |", "Peer", id, "ReceivedAt", entry.contact.ReceivedAt)
+			delete(b.peers, id)
+		}
+	}
+
+	for len(b.peers) > b.opts.MaxPeers {
+		var oldest *peerEntry
+		for _, entry := range b.peers {
+			if !entry.dialing && (oldest == nil || entry.contact.ReceivedAt.Before(oldest.contact.ReceivedAt)) {
+				oldest = entry
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		b.ll.Debug("Forgetting the oldest peer, the peer book is full! |", "Peer", oldest.contact.PeerId, "MaxPeers", b.opts.MaxPeers)
+		delete(b.peers, oldest.contact.PeerId)
+	}
+}
+
+// The latest contact info of the peer.
+func (b *PeerBook) Get(id peer.ID) (PeerContact, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entry, ok := b.peers[id]
+	if !ok {
+		return PeerContact{}, false
+	}
+	return entry.contact, true
+}
+
+func (b *PeerBook) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.peers)
+}
+
+// The outbound connections of the hub & the dials in progress. Must be called with the lock held.
+func (b *PeerBook) outbound() int {
+	count := 0
+	for _, p := range b.host.Network().Peers() {
+		for _, conn := range b.host.Network().ConnsToPeer(p) {
+			if conn.Stat().Direction == network.DirOutbound {
+				count++
+				break
+			}
+		}
+	}
+	for _, entry := range b.peers {
+		if entry.dialing {
+			count++
+		}
+	}
+	return count
+}
+
+// Dials the peer unless it's connected, being dialed, backing off or the hub has enough outbound connections. Must be
+// called with the lock held.
+func (b *PeerBook) maybeDial(entry *peerEntry) {
+	id := entry.contact.PeerId
+	switch {
+	case entry.dialing, b.host.Network().Connectedness(id) == network.Connected:
+		return
+	case b.now().Before(entry.nextDial):
+		b.ll.Debug("Not dialing a peer that couldn't be reached recently! |", "Peer", id, "NextDial", entry.nextDial)
+		return
+	case b.outbound() >= b.opts.MaxOutbound:
+		b.ll.Debug("Not dialing a peer, enough outbound connections! |", "Peer", id, "MaxOutbound", b.opts.MaxOutbound)
+		return
+	}
+
+	addr, err := gossipMultiaddr(id, entry.contact.Body)
+	if err != nil {
+		b.ll.Debug("Can't dial a peer from its contact info! |", "Peer", id, "Error", err)
+		return
+	}
+	maddr, err := multiaddr.NewMultiaddr(addr)
+	if err != nil {
+		b.ll.Debug("Can't dial a peer from its contact info! |", "Peer", id, "Error", err)
+		return
+	}
+	info, err := peer.AddrInfoFromP2pAddr(maddr)
+	if err != nil {
+		b.ll.Debug("Can't dial a peer from its contact info! |", "Peer", id, "Error", err)
+		return
+	}
+
+	entry.dialing = true
+	go func() {
+		ctx, cancel := context.WithTimeout(b.ctx, peerDialTimeout)
+		err := b.host.Connect(ctx, *info)
+		cancel()
+		b.dialed(entry, err)
+	}()
+}
+
+func (b *PeerBook) dialed(entry *peerEntry, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry.dialing = false
+	if err == nil {
+		entry.failures = 0
+		entry.nextDial = time.Time{}
+		b.ll.Info("Connected to peer from contact info! |", "Peer", entry.contact.PeerId)
+		return
+	}
+
+	backoff := peerMinBackoff
+	for i := 0; i < entry.failures && backoff < peerMaxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, peerMaxBackoff)
+	entry.failures++
+	entry.nextDial = b.now().Add(backoff)
+	b.ll.Debug("Couldn't connect to peer from contact info! |", "Peer", entry.contact.PeerId, "Error", err, "Backoff", backoff)
+}
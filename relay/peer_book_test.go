@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/noctisatrae/farseer/config"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func contactInfoBody(port uint32, timestamp uint64) *protos.ContactInfoContentBody {
+	return &protos.ContactInfoContentBody{
+		GossipAddress: &protos.GossipAddressInfo{Address: "127.0.0.1", Family: 4, Port: port},
+		HubVersion:    HUB_VERSION,
+		Network:       protos.FarcasterNetwork_FARCASTER_NETWORK_MAINNET,
+		Timestamp:     timestamp,
+	}
+}
+
+// A contact info with the body, signed like NewContactInfo does.
+func signedContactInfo(t *testing.T, privKey crypto.PrivKey, body *protos.ContactInfoContentBody) *protos.GossipMessage {
+	dataBytes, err := proto.Marshal(body)
+	assert.NoError(t, err)
+	signature, err := privKey.Sign(dataBytes)
+	assert.NoError(t, err)
+	signer, err := crypto.MarshalPublicKey(privKey.GetPublic())
+	assert.NoError(t, err)
+	id, err := peer.IDFromPrivateKey(privKey)
+	assert.NoError(t, err)
+
+	return &protos.GossipMessage{
+		PeerId: []byte(id),
+		Content: &protos.GossipMessage_ContactInfoContent{ContactInfoContent: &protos.ContactInfoContent{
+			Body:      body,
+			Signature: signature,
+			Signer:    signer,
+			DataBytes: dataBytes,
+		}},
+	}
+}
+
+// A host listening on 127.0.0.1 & the port it listens on.
+func newTestPeer(t *testing.T) (host.Host, crypto.PrivKey, uint32) {
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	assert.NoError(t, err)
+	h, err := libp2p.New(libp2p.Identity(privKey), libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { h.Close() })
+
+	port, err := h.Addrs()[0].ValueForProtocol(multiaddr.P_TCP)
+	assert.NoError(t, err)
+	p, err := strconv.ParseUint(port, 10, 16)
+	assert.NoError(t, err)
+	return h, privKey, uint32(p)
+}
+
+func newTestPeerBook(t *testing.T, maxOutbound int) (*PeerBook, host.Host) {
+	hub, _, _ := newTestPeer(t)
+	book := NewPeerBook(context.Background(), hub, PeerBookOptions{
+		Network:       protos.FarcasterNetwork_FARCASTER_NETWORK_MAINNET,
+		MinHubVersion: HUB_VERSION,
+		MaxOutbound:   maxOutbound,
+		MaxPeers:      peerBookSize,
+		ContactTTL:    peerContactTTL,
+	}, *log.Default())
+	return book, hub
+}
+
+// Are only the contact infos signed by their peer, of the same network & of a recent version kept?
+func TestPeerBookVerify(t *testing.T) {
+	// nothing is dialed
+	book, _ := newTestPeerBook(t, 0)
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	assert.NoError(t, err)
+	id, _ := peer.IDFromPrivateKey(privKey)
+
+	// the contact info of NewContactInfo is valid
+	hubContactInfo, err := NewContactInfo(config.HubParams{PublicHubIp: "127.0.0.1", GossipPort: 2282, Network: 1}, privKey, time.UnixMilli(1000))
+	assert.NoError(t, err)
+	contact, err := book.Add(&protos.GossipMessage{
+		PeerId:  []byte(id),
+		Content: &protos.GossipMessage_ContactInfoContent{ContactInfoContent: hubContactInfo},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, id, contact.PeerId)
+	assert.Equal(t, uint64(1000), contact.Timestamp)
+	known, ok := book.Get(id)
+	assert.True(t, ok)
+	assert.Equal(t, contact, known)
+
+	otherKey, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	assert.NoError(t, err)
+	otherId, _ := peer.IDFromPrivateKey(otherKey)
+
+	tests := map[string]func() *protos.GossipMessage{
+		"isn't signed": func() *protos.GossipMessage {
+			m := signedContactInfo(t, privKey, contactInfoBody(2282, 2000))
+			m.GetContactInfoContent().Signature = nil
+			return m
+		},
+		"signed by another peer": func() *protos.GossipMessage {
+			m := signedContactInfo(t, privKey, contactInfoBody(2282, 2000))
+			m.PeerId = []byte(otherId)
+			return m
+		},
+		"invalid signature": func() *protos.GossipMessage {
+			m := signedContactInfo(t, privKey, contactInfoBody(2282, 2000))
+			m.GetContactInfoContent().DataBytes, _ = proto.Marshal(contactInfoBody(2283, 2000))
+			return m
+		},
+		"invalid peer id": func() *protos.GossipMessage {
+			m := signedContactInfo(t, privKey, contactInfoBody(2282, 2000))
+			m.PeerId = []byte("not a peer id")
+			return m
+		},
+		"is part of FARCASTER_NETWORK_TESTNET": func() *protos.GossipMessage {
+			body := contactInfoBody(2282, 2000)
+			body.Network = protos.FarcasterNetwork_FARCASTER_NETWORK_TESTNET
+			return signedContactInfo(t, privKey, body)
+		},
+		"runs version 2024.6.4, older than " + HUB_VERSION: func() *protos.GossipMessage {
+			body := contactInfoBody(2282, 2000)
+			body.HubVersion = "2024.6.4"
+			return signedContactInfo(t, privKey, body)
+		},
+		"invalid hub version": func() *protos.GossipMessage {
+			body := contactInfoBody(2282, 2000)
+			body.HubVersion = "latest"
+			return signedContactInfo(t, privKey, body)
+		},
+		"already sent a newer contact info": func() *protos.GossipMessage {
+			return signedContactInfo(t, privKey, contactInfoBody(2282, 1000))
+		},
+	}
+	for message, contactInfo := range tests {
+		_, err := book.Add(contactInfo())
+		assert.ErrorContains(t, err, message)
+	}
+	known, _ = book.Get(id)
+	assert.Equal(t, contact, known)
+
+	// a newer contact info from a newer version replaces the previous one
+	body := contactInfoBody(2284, 3000)
+	body.HubVersion = "2024.10.1"
+	_, err = book.Add(signedContactInfo(t, privKey, body))
+	assert.NoError(t, err)
+	known, _ = book.Get(id)
+	assert.Equal(t, uint64(3000), known.Timestamp)
+	assert.Equal(t, uint32(2284), known.Body.GossipAddress.Port)
+	assert.Equal(t, 1, book.Len())
+}
+
+// Are the peers dialed until the hub has enough outbound connections?
+func TestPeerBookDial(t *testing.T) {
+	book, hub := newTestPeerBook(t, 1)
+	first, firstKey, firstPort := newTestPeer(t)
+	second, secondKey, secondPort := newTestPeer(t)
+
+	_, err := book.Add(signedContactInfo(t, firstKey, contactInfoBody(firstPort, 1000)))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return hub.Network().Connectedness(first.ID()) == network.Connected
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// the limit is reached
+	_, err = book.Add(signedContactInfo(t, secondKey, contactInfoBody(secondPort, 1000)))
+	assert.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+	assert.NotEqual(t, network.Connected, hub.Network().Connectedness(second.ID()))
+
+	// a connected peer isn't dialed again
+	book.opts.MaxOutbound = 10
+	_, err = book.Add(signedContactInfo(t, firstKey, contactInfoBody(firstPort, 2000)))
+	assert.NoError(t, err)
+	assert.Len(t, hub.Network().ConnsToPeer(first.ID()), 1)
+
+	_, err = book.Add(signedContactInfo(t, secondKey, contactInfoBody(secondPort, 2000)))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return hub.Network().Connectedness(second.ID()) == network.Connected
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+// Does the peer book wait longer & longer before dialing again a peer it couldn't reach?
+func TestPeerBookBackoff(t *testing.T) {
+	book, _ := newTestPeerBook(t, 10)
+	now := time.Now()
+	book.now = func() time.Time { return now }
+
+	unreachable, privKey, port := newTestPeer(t)
+	unreachable.Close()
+
+	failures := func() int {
+		book.mu.Lock()
+		defer book.mu.Unlock()
+		entry := book.peers[unreachable.ID()]
+		if entry.dialing {
+			return -1
+		}
+		return entry.failures
+	}
+
+	_, err := book.Add(signedContactInfo(t, privKey, contactInfoBody(port, 1000)))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return failures() == 1 }, 5*time.Second, 50*time.Millisecond)
+
+	// backing off
+	_, err = book.Add(signedContactInfo(t, privKey, contactInfoBody(port, 2000)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, failures())
+
+	now = now.Add(peerMinBackoff)
+	_, err = book.Add(signedContactInfo(t, privKey, contactInfoBody(port, 3000)))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return failures() == 2 }, 5*time.Second, 50*time.Millisecond)
+
+	// the backoff doubled
+	now = now.Add(peerMinBackoff)
+	_, err = book.Add(signedContactInfo(t, privKey, contactInfoBody(port, 4000)))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, failures())
+	book.mu.Lock()
+	assert.Equal(t, now.Add(peerMinBackoff), book.peers[unreachable.ID()].nextDial)
+	book.mu.Unlock()
+}
+
+// Are the peers forgotten once their contact info is stale, then the oldest ones when the book is full?
+func TestPeerBookEviction(t *testing.T) {
+	// nothing is dialed
+	book, _ := newTestPeerBook(t, 0)
+	book.opts.MaxPeers = 2
+	book.opts.ContactTTL = time.Hour
+	now := time.Now()
+	book.now = func() time.Time { return now }
+
+	add := func() peer.ID {
+		privKey, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+		assert.NoError(t, err)
+		contact, err := book.Add(signedContactInfo(t, privKey, contactInfoBody(2282, 1000)))
+		assert.NoError(t, err)
+		now = now.Add(time.Second)
+		return contact.PeerId
+	}
+
+	first, second, third := add(), add(), add()
+	assert.Equal(t, 2, book.Len())
+	_, ok := book.Get(first)
+	assert.False(t, ok, "the oldest peer is forgotten")
+	_, ok = book.Get(second)
+	assert.True(t, ok)
+
+	now = now.Add(time.Hour)
+	fourth := add()
+	assert.Equal(t, 1, book.Len())
+	for _, id := range []peer.ID{second, third} {
+		_, ok = book.Get(id)
+		assert.False(t, ok, "the stale peers are forgotten")
+	}
+	_, ok = book.Get(fourth)
+	assert.True(t, ok)
+
+	// a peer being dialed is kept, so it's still counted as an outbound connection
+	book.peers[fourth].dialing = true
+	now = now.Add(time.Hour)
+	fifth, sixth := add(), add()
+	assert.Equal(t, 2, book.Len())
+	for _, id := range []peer.ID{fourth, sixth} {
+		_, ok = book.Get(id)
+		assert.True(t, ok)
+	}
+	_, ok = book.Get(fifth)
+	assert.False(t, ok)
+
+	// there's no room for the new peer at all
+	book.opts.MaxPeers = 0
+	book.peers[sixth].dialing = true
+	privKey, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	assert.NoError(t, err)
+	_, err = book.Add(signedContactInfo(t, privKey, contactInfoBody(2282, 1000)))
+	assert.Error(t, err)
+	assert.Equal(t, 2, book.Len())
+}
+
+// Are the versions of the protocol compared by date?
+func TestCompareHubVersions(t *testing.T) {
+	cmp, err := compareHubVersions("2024.7.24", "2024.10.1")
+	assert.NoError(t, err)
+	assert.Less(t, cmp, 0)
+
+	cmp, err = compareHubVersions("2025.1.1", "2024.12.31")
+	assert.NoError(t, err)
+	assert.Greater(t, cmp, 0)
+
+	cmp, err = compareHubVersions(HUB_VERSION, HUB_VERSION)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cmp)
+
+	_, err = compareHubVersions("2024.7", HUB_VERSION)
+	assert.Error(t, err)
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/noctisatrae/farseer/handlers"
+	protos "github.com/noctisatrae/farseer/protos"
+	"github.com/noctisatrae/farseer/utils"
+
+	"github.com/charmbracelet/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// How long a plugin has to start listening & run its InitHandler.
+	pluginInitTimeout = 30 * time.Second
+	// How long a plugin has to handle a message before being considered hung & restarted. A plugin that can't take
+	// a message yet answers right away that it's busy instead.
+	pluginCallTimeout = 30 * time.Second
+	// Delays between the attempts to send a message to a busy plugin.
+	pluginBusyMinBackoff = 100 * time.Millisecond
+	pluginBusyMaxBackoff = 5 * time.Second
+	// How long a plugin has to exit after SIGTERM before being killed.
+	pluginStopTimeout = 5 * time.Second
+	// Delays between the restarts of a plugin that keeps crashing. The delay is reset once it runs for longer than
+	// the maximum.
+	pluginMinBackoff = time.Second
+	pluginMaxBackoff = time.Minute
+	// A message that was being handled when the plugin crashed is sent again to the new process, but only this many
+	// times so a message crashing the plugin can't stop it for good.
+	pluginMaxDeliveries = 3
+)
+
+// The method of the HandlerPlugin service matching every type of message.
+var pluginMethods = map[protos.MessageType]func(protos.HandlerPluginClient, context.Context, *protos.PluginMessage, ...grpc.CallOption) (*protos.PluginResponse, error){
+	protos.MessageType_MESSAGE_TYPE_CAST_ADD:                     protos.HandlerPluginClient.CastAdd,
+	protos.MessageType_MESSAGE_TYPE_CAST_REMOVE:                  protos.HandlerPluginClient.CastRemove,
+	protos.MessageType_MESSAGE_TYPE_FRAME_ACTION:                 protos.HandlerPluginClient.FrameAction,
+	protos.MessageType_MESSAGE_TYPE_REACTION_ADD:                 protos.HandlerPluginClient.ReactionAdd,
+	protos.MessageType_MESSAGE_TYPE_REACTION_REMOVE:              protos.HandlerPluginClient.ReactionRemove,
+	protos.MessageType_MESSAGE_TYPE_LINK_ADD:                     protos.HandlerPluginClient.LinkAdd,
+	protos.MessageType_MESSAGE_TYPE_LINK_REMOVE:                  protos.HandlerPluginClient.LinkRemove,
+	protos.MessageType_MESSAGE_TYPE_VERIFICATION_ADD_ETH_ADDRESS: protos.HandlerPluginClient.VerificationAdd,
+	protos.MessageType_MESSAGE_TYPE_VERIFICATION_REMOVE:          protos.HandlerPluginClient.VerificationRemove,
+	protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD:                protos.HandlerPluginClient.UserDataAdd,
+	protos.MessageType_MESSAGE_TYPE_USERNAME_PROOF:               protos.HandlerPluginClient.UsernameProof,
+	protos.MessageType_MESSAGE_TYPE_LINK_COMPACT_STATE:           protos.HandlerPluginClient.LinkCompactState,
+}
+
+type pendingMessage struct {
+	message    *protos.PluginMessage
+	deliveries int
+}
+
+// A plugin running in its own process. The relay launches it, sends it the messages of its subscription over a Unix
+// socket & restarts it when it crashes, so a faulty plugin can't take the hub down.
+type PluginProcess struct {
+	Name string
+
+	command    []string
+	config     []byte
+	socketPath string
+	sub        *Subscriber
+	ll         log.Logger
+	// how long a call can take, replaced by the tests
+	callTimeout time.Duration
+
+	// messages read from the subscription that the plugin hasn't handled yet, kept across restarts
+	pending []pendingMessage
+}
+
+func NewPluginProcess(name string, command []string, config []byte, sub *Subscriber, ll log.Logger) *PluginProcess {
+	return &PluginProcess{
+		Name:        name,
+		command:     command,
+		config:      config,
+		socketPath:  filepath.Join(os.TempDir(), fmt.Sprintf("farseer-%s-%d.sock", name, os.Getpid())),
+		sub:         sub,
+		ll:          ll,
+		callTimeout: pluginCallTimeout,
+		pending:     []pendingMessage{},
+	}
+}
+
+// Refused by the plugin when it's initialized: restarting it won't help, its config has to be fixed.
+var errPluginConfig = errors.New("the plugin refused its config")
+
+// Runs the plugin until its subscription is closed or the context is cancelled, restarting it with an exponential
+// backoff when it stops. It's only given up on when it refuses its config.
+func (p *PluginProcess) Run(ctx context.Context) error {
+	backoff := pluginMinBackoff
+	for {
+		startedAt := time.Now()
+		done, err := p.runOnce(ctx)
+		if errors.Is(err, errPluginConfig) {
+			return err
+		}
+		if done {
+			p.ll.Info("Plugin stopped! |", "Name", p.Name)
+			return nil
+		}
+
+		if time.Since(startedAt) > pluginMaxBackoff {
+			backoff = pluginMinBackoff
+		}
+		p.ll.Error("Plugin stopped unexpectedly, restarting it! |", "Name", p.Name, "Error", err, "Backoff", backoff)
+		if done := p.wait(ctx, backoff); done {
+			p.ll.Info("Plugin stopped! |", "Name", p.Name)
+			return nil
+		}
+		backoff = min(backoff*2, pluginMaxBackoff)
+	}
+}
+
+// Waits before restarting the plugin while still reading its subscription, so a crashing plugin can't hold back
+// the dispatcher. The messages are kept for the new process until they'd overflow the buffer of the subscription,
+// the next ones are dropped & counted.
+func (p *PluginProcess) wait(ctx context.Context, backoff time.Duration) (done bool) {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	dropped := 0
+	defer func() {
+		if dropped > 0 {
+			p.ll.Warn("Dropped messages while the plugin was restarting! |", "Name", p.Name, "Dropped", dropped)
+		}
+	}()
+	for {
+		select {
+		case msgB, ok := <-p.sub.Messages:
+			if !ok {
+				return true
+			}
+			if len(p.pending) >= cap(p.sub.Messages) {
+				p.sub.drop()
+				dropped++
+				continue
+			}
+			p.queue(msgB)
+		case <-timer.C:
+			return false
+		case <-ctx.Done():
+			return true
+		}
+	}
+}
+
+// Adds the messages of a gossip message to the ones the plugin has to handle.
+func (p *PluginProcess) queue(msgB *protos.GossipMessage) {
+	msgs, meta := handlers.ExtractMessages(msgB)
+	for _, m := range msgs {
+		if m.GetData() == nil {
+			p.ll.Warn("Received a message without data! |", "Hash", m.GetHash())
+			continue
+		}
+		p.pending = append(p.pending, pendingMessage{
+			message: &protos.PluginMessage{Message: m, BundleHash: meta.BundleHash, PeerId: meta.PeerId},
+		})
+	}
+}
+
+// Launches the plugin & feeds it until the subscription is closed (done) or the plugin fails.
+func (p *PluginProcess) runOnce(parent context.Context) (done bool, err error) {
+	if err := os.Remove(p.socketPath); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	loadedPlugins.Starting(p.Name, pluginKindProcess)
+	cmd := exec.Command(p.command[0], p.command[1:]...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", handlers.PluginSocketEnv, p.socketPath))
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return false, err
+	}
+	p.ll.Info("Plugin launched! |", "Name", p.Name, "Pid", cmd.Process.Pid)
+	defer os.Remove(p.socketPath)
+
+	exited := make(chan struct{})
+	var exitErr error
+	go func() {
+		exitErr = cmd.Wait()
+		close(exited)
+	}()
+	defer stopProcess(cmd, exited)
+
+	// calls are cancelled as soon as the process exits instead of waiting for their timeout
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	go func() {
+		select {
+		case <-exited:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	processError := func(err error) error {
+		select {
+		case <-exited:
+			return fmt.Errorf("the plugin exited: %v", exitErr)
+		default:
+			return err
+		}
+	}
+
+	conn, err := grpc.NewClient("unix://"+p.socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	client := protos.NewHandlerPluginClient(conn)
+
+	initCtx, initCancel := context.WithTimeout(ctx, pluginInitTimeout)
+	res, err := client.Init(initCtx, &protos.PluginInitRequest{Config: p.config}, grpc.WaitForReady(true))
+	initCancel()
+	if err != nil {
+		if parent.Err() != nil {
+			return true, nil
+		}
+		if status.Code(err) == codes.InvalidArgument {
+			err = fmt.Errorf("%w: %s", errPluginConfig, status.Convert(err).Message())
+			loadedPlugins.Initialized(p.Name, err)
+			return true, err
+		}
+		err = processError(fmt.Errorf("couldn't init the plugin: %w", err))
+		loadedPlugins.Initialized(p.Name, err)
+		return false, err
+	}
+	loadedPlugins.Initialized(p.Name, nil)
+
+	handled := map[protos.MessageType]bool{}
+	for _, msgType := range res.MessageTypes {
+		handled[msgType] = true
+	}
+	p.ll.Debug("Plugin is ready! |", "Name", p.Name, "PluginName", res.Name, "MessageTypes", res.MessageTypes)
+
+	busyBackoff := pluginBusyMinBackoff
+	for {
+		for len(p.pending) > 0 {
+			pending := &p.pending[0]
+			msgType := pending.message.Message.Data.Type
+
+			method, ok := pluginMethods[msgType]
+			if !ok || !handled[msgType] {
+				p.pending = p.pending[1:]
+				continue
+			}
+
+			pending.deliveries++
+			startedAt := time.Now()
+			callCtx, callCancel := context.WithTimeout(ctx, p.callTimeout)
+			_, err := method(client, callCtx, pending.message)
+			callCancel()
+			observePluginCall(p.Name, msgType, startedAt, err)
+			if status.Code(err) == codes.ResourceExhausted {
+				// the plugin is fine, it only asks us to slow down: the message is sent again once we've waited
+				pending.deliveries--
+				p.ll.Debug("Plugin is busy, waiting before sending the message again! |", "Name", p.Name, "Backoff", busyBackoff)
+				select {
+				case <-time.After(busyBackoff):
+				case <-exited:
+					return false, processError(nil)
+				case <-parent.Done():
+					return true, nil
+				}
+				busyBackoff = min(busyBackoff*2, pluginBusyMaxBackoff)
+				continue
+			}
+			busyBackoff = pluginBusyMinBackoff
+			if err != nil && isTransportError(err) {
+				if pending.deliveries >= pluginMaxDeliveries {
+					p.ll.Error("Giving up on a message the plugin couldn't handle! |", "Name", p.Name, "Hash", utils.BytesToHex(pending.message.Message.Hash), "Deliveries", pending.deliveries)
+					p.pending = p.pending[1:]
+				}
+				if parent.Err() != nil {
+					return true, nil
+				}
+				return false, processError(err)
+			} else if err != nil {
+				p.ll.Error("Plugin handler encountered an error! |", "Name", p.Name, "Type", msgType, "Error", status.Convert(err).Message())
+			}
+			p.pending = p.pending[1:]
+		}
+
+		select {
+		case msgB, ok := <-p.sub.Messages:
+			if !ok {
+				return true, nil
+			}
+			p.queue(msgB)
+		case <-exited:
+			return false, processError(nil)
+		case <-parent.Done():
+			return true, nil
+		}
+	}
+}
+
+// Errors of the connection to the plugin, as opposed to the ones returned by its handlers.
+func isTransportError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Canceled, codes.DeadlineExceeded:
+		return true
+	default:
+		return errors.Is(err, context.Canceled)
+	}
+}
+
+// Asks the plugin to exit & kills it if it doesn't in time.
+func stopProcess(cmd *exec.Cmd, exited chan struct{}) {
+	select {
+	case <-exited:
+		return
+	default:
+	}
+
+	_ = cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-exited:
+	case <-time.After(pluginStopTimeout):
+		_ = cmd.Process.Kill()
+		<-exited
+	}
+}
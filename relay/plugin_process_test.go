@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/noctisatrae/farseer/config"
+	"github.com/noctisatrae/farseer/handlers"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// Not a real test: it's the plugin launched by TestPluginProcess, running the test binary in another process.
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv("FARSEER_HELPER_PLUGIN") != "1" {
+		return
+	}
+
+	err := handlers.ServePlugin(refusingPlugin{handlers.FromHandler(handlers.Handler{
+		Name: "helper",
+		InitHandler: func(params map[string]interface{}) error {
+			if params["Output"] == nil {
+				return errors.New("no Output was provided")
+			}
+			return nil
+		},
+		CastAddHandler: func(data *protos.MessageData, hash []byte, params map[string]interface{}) error {
+			output := params["Output"].(string)
+			text := data.GetCastAddBody().GetText()
+
+			// crash the first time we see this message, the relay should send it again to the new process
+			if text == "crash" {
+				if _, err := os.Stat(output + ".crashed"); os.IsNotExist(err) {
+					os.WriteFile(output+".crashed", []byte{}, 0o644)
+					os.Exit(1)
+				}
+			}
+			// hang the first time we see this message, the relay should restart the plugin once the call times out
+			if text == "hang" {
+				if _, err := os.Stat(output + ".hung"); os.IsNotExist(err) {
+					os.WriteFile(output+".hung", []byte{}, 0o644)
+					select {}
+				}
+			}
+			// refuse this message the first time we see it, the relay should send it again to the same process
+			if text == "busy" {
+				if _, err := os.Stat(output + ".busy"); os.IsNotExist(err) {
+					os.WriteFile(output+".busy", []byte{}, 0o644)
+					return fmt.Errorf("queue is full: %w", handlers.ErrBusy)
+				}
+			}
+			if text == "fail" {
+				return errors.New("this cast is refused")
+			}
+
+			f, err := os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = f.WriteString(text + "\n")
+			return err
+		},
+	})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Exit(0)
+}
+
+// Refuses the configs asking for it, like a plugin finding an unknown key.
+type refusingPlugin struct {
+	handlers.Plugin
+}
+
+func (p refusingPlugin) Configure(raw []byte) error {
+	if strings.Contains(string(raw), "Refuse") {
+		return errors.New("unknown key Refuse")
+	}
+	return p.Plugin.Configure(raw)
+}
+
+// Are the messages sent to a plugin running in its own process, even when it crashes, hangs or is busy?
+func TestPluginProcess(t *testing.T) {
+	log.SetLevel(log.DebugLevel)
+	t.Setenv("FARSEER_HELPER_PLUGIN", "1")
+
+	output := filepath.Join(t.TempDir(), "casts.txt")
+	conf := config.Config{
+		Hub: config.HubParams{BufferSize: 16},
+		Handlers: map[string]interface{}{
+			"helper": map[string]interface{}{
+				"Enabled":    true,
+				"Command":    []interface{}{os.Args[0], "-test.run=^TestHelperPlugin$"},
+				"Validation": "raw",
+				"Output":     output,
+			},
+		},
+	}
+
+	dispatchParams := conf.GetDispatchParams("helper")
+	dispatcher := NewDispatcher(*log.Default())
+	sub := dispatcher.Subscribe("helper", SubscribeOptions{BufferSize: dispatchParams.BufferSize, Backpressure: BackpressureBlock})
+	pluginConfig, err := toml.Marshal(conf.GetParams("helper"))
+	assert.NoError(t, err)
+	p := NewPluginProcess("helper", dispatchParams.Command, pluginConfig, sub, *log.Default())
+	p.callTimeout = 500 * time.Millisecond
+
+	done := make(chan struct{})
+	go func() {
+		p.Run(context.Background())
+		close(done)
+	}()
+
+	messages := make(chan *protos.GossipMessage)
+	go dispatcher.Run(messages)
+
+	cast := func(text string) *protos.Message {
+		return &protos.Message{Data: &protos.MessageData{
+			Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD, Fid: 10626,
+			Body: &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: text}},
+		}}
+	}
+	messages <- &protos.GossipMessage{Content: &protos.GossipMessage_Message{Message: cast("gm")}}
+	messages <- &protos.GossipMessage{Content: &protos.GossipMessage_MessageBundle{MessageBundle: &protos.MessageBundle{
+		Messages: []*protos.Message{cast("fail"), cast("crash"), cast("hang"), cast("busy"), cast("gn")},
+	}}}
+
+	assert.Eventually(t, func() bool {
+		content, _ := os.ReadFile(output)
+		return string(content) == "gm\ncrash\nhang\nbusy\ngn\n"
+		// the hung plugin is only killed after pluginStopTimeout
+	}, 20*time.Second, 50*time.Millisecond)
+
+	close(messages)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the plugin didn't stop")
+	}
+
+	_, err = os.Stat(output + ".crashed")
+	assert.NoError(t, err)
+	_, err = os.Stat(output + ".hung")
+	assert.NoError(t, err)
+	_, err = os.Stat(output + ".busy")
+	assert.NoError(t, err)
+	_, err = os.Stat(p.socketPath)
+	assert.True(t, os.IsNotExist(err), "the socket should be removed")
+}
+
+// Does a restarting plugin keep reading its subscription, so the dispatcher isn't blocked until it's back?
+func TestPluginProcessBackoff(t *testing.T) {
+	dispatcher := NewDispatcher(*log.Default())
+	sub := dispatcher.Subscribe("helper", SubscribeOptions{BufferSize: 2, Backpressure: BackpressureBlock})
+	p := NewPluginProcess("helper", []string{"helper"}, nil, sub, *log.Default())
+
+	waited := make(chan bool)
+	go func() { waited <- p.wait(context.Background(), 500*time.Millisecond) }()
+
+	dispatched := make(chan struct{})
+	go func() {
+		for i := 0; i < 6; i++ {
+			dispatcher.Dispatch(&protos.GossipMessage{Content: &protos.GossipMessage_Message{Message: &protos.Message{
+				Data: &protos.MessageData{Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD, Fid: uint64(i)},
+			}}})
+		}
+		close(dispatched)
+	}()
+	select {
+	case <-dispatched:
+	case <-time.After(time.Second):
+		t.Fatal("the dispatcher was blocked by the restarting plugin")
+	}
+
+	assert.False(t, <-waited)
+	assert.Len(t, p.pending, 2, "the messages fitting in the buffer are kept for the new process")
+	assert.Equal(t, uint64(4), sub.Dropped())
+
+	dispatcher.Unsubscribe(sub)
+	assert.True(t, p.wait(context.Background(), time.Minute), "the plugin stops once its subscription is closed")
+}
+
+// Is a plugin refusing its config given up on instead of being restarted forever?
+func TestPluginProcessConfigRefused(t *testing.T) {
+	t.Setenv("FARSEER_HELPER_PLUGIN", "1")
+
+	dispatcher := NewDispatcher(*log.Default())
+	sub := dispatcher.Subscribe("refused", SubscribeOptions{BufferSize: 1, Backpressure: BackpressureBlock})
+	p := NewPluginProcess("refused", []string{os.Args[0], "-test.run=^TestHelperPlugin$"}, []byte("Refuse = true"), sub, *log.Default())
+
+	done := make(chan error)
+	go func() { done <- p.Run(context.Background()) }()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, errPluginConfig)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the plugin is restarted")
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/noctisatrae/farseer/config"
+	"github.com/noctisatrae/farseer/handlers"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const replayUsage = `Usage:
+  relay replay --input capture.ndjson [flags]
+
+Feeds the messages of a capture to the plugins, without connecting to the network. The capture is either:
+  - NDJSON: a GossipMessage or a Message encoded with protojson on every line
+  - protodelim: length-delimited Messages, as written by the archive plugin
+optionally compressed with zstd (.zst).
+
+Flags:
+`
+
+type ReplayOptions struct {
+	// Path of the capture.
+	Input string
+	// The plugins the messages are given to, all the enabled ones if empty.
+	Handlers []string
+	// 1 replays the messages at the pace they were sent, 2 twice as fast... 0 as fast as possible.
+	Speed float64
+	// Reads the capture without giving the messages to the plugins.
+	DryRun bool
+}
+
+// What was replayed.
+type ReplayStats struct {
+	GossipMessages uint64
+	Messages       map[protos.MessageType]uint64
+}
+
+func replay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	input := flags.String("input", "", "path of the capture to replay")
+	handlerNames := flags.String("handlers", "", "comma-separated plugins the messages are given to, defaults to the enabled ones")
+	speed := flags.Float64("speed", 0, "1 replays at the pace of the capture, 2 twice as fast... 0 as fast as possible")
+	dryRun := flags.Bool("dry-run", false, "read the capture without giving the messages to the plugins")
+	configPath := flags.String("config", "config.toml", "config of the hub, where the plugins are defined")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), replayUsage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *input == "" || *speed < 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	conf, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	if conf.Hub.Debug {
+		log.SetLevel(log.DebugLevel)
+	}
+
+	opts := ReplayOptions{Input: *input, Speed: *speed, DryRun: *dryRun}
+	if *handlerNames != "" {
+		opts.Handlers = strings.Split(*handlerNames, ",")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	stats, err := Replay(ctx, conf, opts, *log.WithPrefix("replay"))
+	for msgType, count := range stats.Messages {
+		log.Info("Replayed! |", "Type", msgType, "Messages", count)
+	}
+	log.Info("Done! |", "GossipMessages", stats.GossipMessages, "DryRun", opts.DryRun)
+	return err
+}
+
+// Loads the plugins with LoadHandlersFromConf, then dispatches the messages of the capture to them like the
+// gossip would. It returns once the plugins are done with every message, or when the context is cancelled.
+func Replay(ctx context.Context, conf config.Config, opts ReplayOptions, ll log.Logger) (ReplayStats, error) {
+	stats := ReplayStats{Messages: map[protos.MessageType]uint64{}}
+
+	capture, err := openCapture(opts.Input)
+	if err != nil {
+		return stats, err
+	}
+	defer capture.Close()
+
+	conf, err = selectHandlers(conf, opts.Handlers)
+	if err != nil {
+		return stats, err
+	}
+
+	dispatcher := NewDispatcher(ll)
+	var wg sync.WaitGroup
+	pluginCtx, stopPlugins := context.WithCancel(context.Background())
+	defer stopPlugins()
+	if !opts.DryRun {
+		if err := LoadHandlersFromConf(pluginCtx, conf, dispatcher, &wg, ll); err != nil {
+			return stats, err
+		}
+	}
+
+	messages := make(chan *protos.GossipMessage)
+	dispatched := make(chan struct{})
+	go func() {
+		dispatcher.Run(messages)
+		close(dispatched)
+	}()
+
+	var previous uint32
+	for {
+		msgB, err := capture.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			close(messages)
+			<-dispatched
+			wg.Wait()
+			return stats, err
+		}
+
+		msgs, _ := handlers.ExtractMessages(msgB)
+		if len(msgs) > 0 && opts.Speed > 0 {
+			// the farseer timestamps are in seconds
+			timestamp := msgs[0].GetData().GetTimestamp()
+			if previous != 0 && timestamp > previous {
+				delay := time.Duration(float64(time.Duration(timestamp-previous)*time.Second) / opts.Speed)
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+				}
+			}
+			if timestamp > previous {
+				previous = timestamp
+			}
+		}
+		if ctx.Err() != nil {
+			ll.Warn("Stopping the replay! |", "GossipMessages", stats.GossipMessages)
+			break
+		}
+
+		stats.GossipMessages++
+		for _, m := range msgs {
+			stats.Messages[m.GetData().GetType()]++
+		}
+		if opts.DryRun {
+			ll.Debug("Read a message! |", "Messages", len(msgs))
+			continue
+		}
+		messages <- msgB
+	}
+
+	// the plugins stop once they've handled every message
+	close(messages)
+	<-dispatched
+	wg.Wait()
+
+	return stats, nil
+}
+
+// Only keeps the given plugins in the config, enabled even if they weren't.
+func selectHandlers(conf config.Config, names []string) (config.Config, error) {
+	if len(names) == 0 {
+		return conf, nil
+	}
+
+	selected := map[string]interface{}{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		params, ok := conf.Handlers[name].(map[string]interface{})
+		if !ok {
+			return conf, fmt.Errorf("no [handlers.%s] in the config", name)
+		}
+
+		enabled := map[string]interface{}{}
+		for k, v := range params {
+			enabled[k] = v
+		}
+		enabled["Enabled"] = true
+		selected[name] = enabled
+	}
+
+	conf.Handlers = selected
+	return conf, nil
+}
+
+// A capture being read, one GossipMessage at a time.
+type captureReader struct {
+	file    *os.File
+	decoder *zstd.Decoder
+	next    func() (*protos.GossipMessage, error)
+}
+
+// Opens a capture, its format is given by its extension: .protodelim for length-delimited Messages, NDJSON
+// otherwise. A .zst capture is decompressed.
+func openCapture(path string) (*captureReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &captureReader{file: file}
+
+	var r io.Reader = file
+	name := path
+	if strings.HasSuffix(name, ".zst") {
+		c.decoder, err = zstd.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		r = c.decoder
+		name = strings.TrimSuffix(name, ".zst")
+	}
+
+	if filepath.Ext(name) == ".protodelim" {
+		reader := bufio.NewReader(r)
+		c.next = func() (*protos.GossipMessage, error) {
+			m := &protos.Message{}
+			if err := protodelim.UnmarshalFrom(reader, m); err != nil {
+				return nil, err
+			}
+			return &protos.GossipMessage{Content: &protos.GossipMessage_Message{Message: m}}, nil
+		}
+		return c, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	// a bundle can be big
+	scanner.Buffer(nil, 16<<20)
+	line := 0
+	c.next = func() (*protos.GossipMessage, error) {
+		for scanner.Scan() {
+			line++
+			if len(strings.TrimSpace(scanner.Text())) == 0 {
+				continue
+			}
+			msgB, err := parseCaptureLine(scanner.Bytes())
+			if err != nil {
+				return nil, fmt.Errorf("line %d of %s: %w", line, path, err)
+			}
+			return msgB, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return c, nil
+}
+
+// A line is a GossipMessage, or a Message as written by the archive plugin.
+func parseCaptureLine(line []byte) (*protos.GossipMessage, error) {
+	msgB := &protos.GossipMessage{}
+	gossipErr := protojson.Unmarshal(line, msgB)
+	if gossipErr == nil {
+		return msgB, nil
+	}
+
+	m := &protos.Message{}
+	if err := protojson.Unmarshal(line, m); err != nil {
+		return nil, errors.Join(gossipErr, err)
+	}
+	return &protos.GossipMessage{Content: &protos.GossipMessage_Message{Message: m}}, nil
+}
+
+func (c *captureReader) Next() (*protos.GossipMessage, error) {
+	return c.next()
+}
+
+func (c *captureReader) Close() error {
+	if c.decoder != nil {
+		c.decoder.Close()
+	}
+	return c.file.Close()
+}
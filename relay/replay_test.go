@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/noctisatrae/farseer/config"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func castAt(text string, timestamp uint32) *protos.Message {
+	return &protos.Message{Data: &protos.MessageData{
+		Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD, Fid: 10626, Timestamp: timestamp,
+		Body: &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: text}},
+	}}
+}
+
+// Writes a capture where the lines are GossipMessages or Messages, like the archive plugin writes them.
+func writeNDJSONCapture(t *testing.T, lines ...[]byte) string {
+	path := filepath.Join(t.TempDir(), "capture.ndjson")
+	content := bytes.Join(lines, []byte("\n"))
+	assert.NoError(t, os.WriteFile(path, content, 0o644))
+	return path
+}
+
+// Are the messages of the capture given to the plugins named, even the disabled ones?
+func TestReplay(t *testing.T) {
+	log.SetLevel(log.DebugLevel)
+	t.Setenv("FARSEER_HELPER_PLUGIN", "1")
+
+	output := filepath.Join(t.TempDir(), "casts.txt")
+	conf := config.Config{
+		Hub: config.HubParams{BufferSize: 16},
+		Handlers: map[string]interface{}{
+			"helper": map[string]interface{}{
+				"Enabled":    false,
+				"Command":    []interface{}{os.Args[0], "-test.run=^TestHelperPlugin$"},
+				"Validation": "raw",
+				"Output":     output,
+			},
+		},
+	}
+
+	gossip, err := protojson.Marshal(&protos.GossipMessage{Content: &protos.GossipMessage_MessageBundle{MessageBundle: &protos.MessageBundle{
+		Messages: []*protos.Message{castAt("gm", 1), castAt("fail", 1)},
+	}}})
+	assert.NoError(t, err)
+	message, err := protojson.Marshal(castAt("gn", 2))
+	assert.NoError(t, err)
+	input := writeNDJSONCapture(t, gossip, []byte{}, message)
+
+	stats, err := Replay(context.Background(), conf, ReplayOptions{Input: input, Handlers: []string{"helper"}}, *log.Default())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), stats.GossipMessages)
+	assert.Equal(t, map[protos.MessageType]uint64{protos.MessageType_MESSAGE_TYPE_CAST_ADD: 3}, stats.Messages)
+
+	// the plugins are done once Replay returns
+	content, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "gm\ngn\n", string(content))
+
+	_, err = Replay(context.Background(), conf, ReplayOptions{Input: input, Handlers: []string{"unknown"}}, *log.Default())
+	assert.Error(t, err)
+}
+
+// Can a compressed protodelim archive be read without loading the plugins?
+func TestReplayDryRun(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "farseer-20240601T000000.000000000Z.protodelim.zst")
+	f, err := os.Create(input)
+	assert.NoError(t, err)
+	encoder, err := zstd.NewWriter(f)
+	assert.NoError(t, err)
+	for i := range 3 {
+		_, err := protodelim.MarshalTo(encoder, castAt("gm", uint32(i)))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, encoder.Close())
+	assert.NoError(t, f.Close())
+
+	// the plugin would fail to start without its Output
+	conf := config.Config{Handlers: map[string]interface{}{
+		"helper": map[string]interface{}{"Enabled": true, "Command": []interface{}{"false"}},
+	}}
+	stats, err := Replay(context.Background(), conf, ReplayOptions{Input: input, DryRun: true}, *log.Default())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), stats.GossipMessages)
+}
+
+// Are the messages spaced out like in the capture, sped up by Speed?
+func TestReplaySpeed(t *testing.T) {
+	first, err := protojson.Marshal(castAt("gm", 100))
+	assert.NoError(t, err)
+	second, err := protojson.Marshal(castAt("gn", 101))
+	assert.NoError(t, err)
+	input := writeNDJSONCapture(t, first, second)
+
+	start := time.Now()
+	stats, err := Replay(context.Background(), config.Config{}, ReplayOptions{Input: input, Speed: 5, DryRun: true}, *log.Default())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), stats.GossipMessages)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	// stopped while waiting for the second message
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	stats, err = Replay(ctx, config.Config{}, ReplayOptions{Input: input, Speed: 0.001, DryRun: true}, *log.Default())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), stats.GossipMessages)
+}
+
+// Is a line that isn't a message reported with its number?
+func TestReplayInvalidCapture(t *testing.T) {
+	message, err := protojson.Marshal(castAt("gm", 1))
+	assert.NoError(t, err)
+	input := writeNDJSONCapture(t, message, []byte("not json"))
+
+	stats, err := Replay(context.Background(), config.Config{}, ReplayOptions{Input: input, DryRun: true}, *log.Default())
+	assert.ErrorContains(t, err, "line 2")
+	assert.Equal(t, uint64(1), stats.GossipMessages)
+}
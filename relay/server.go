@@ -5,31 +5,67 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	gotime "time"
 
 	"github.com/noctisatrae/farseer/config"
+	"github.com/noctisatrae/farseer/handlers"
 	protos "github.com/noctisatrae/farseer/protos"
+	"github.com/noctisatrae/farseer/store"
 	"github.com/noctisatrae/farseer/time"
 	"github.com/noctisatrae/farseer/utils"
+	"github.com/noctisatrae/farseer/validation"
 
 	"github.com/charmbracelet/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 type hubRPCServer struct {
 	// utils
-	netw Network
-	ll   log.Logger
+	netw       *Network
+	ll         log.Logger
+	network    protos.FarcasterNetwork
+	store      *store.Store
+	dispatcher *Dispatcher
+	bufferSize uint
+	eventIds   eventIdGenerator
 
 	protos.UnimplementedHubServiceServer
 	rpcServer map[string][]*protos.HubServiceServer
 }
 
+// Maps the errors of the hub to the gRPC status codes used by Hubble.
+func toServiceError(err error) error {
+	switch {
+	case strings.HasPrefix(err.Error(), "bad_request"):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case strings.HasPrefix(err.Error(), "not_found"):
+		return status.Error(codes.NotFound, err.Error())
+	case strings.HasPrefix(err.Error(), "unavailable"):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
 func (s *hubRPCServer) SubmitMessage(ctx context.Context, message *protos.Message) (*protos.Message, error) {
+	err := validation.ValidateMessageForNetwork(message, s.network)
+	if err != nil {
+		submitMessageOutcomes.WithLabelValues("invalid").Inc()
+		s.ll.Debug("Rejected a message from gRPC! |", "Error", err, "Hash", utils.BytesToHex(message.Hash))
+		return nil, toServiceError(err)
+	}
+
 	peerIdEncoded, err := s.netw.self.Marshal()
 	if err != nil {
-		return &protos.Message{}, err
+		return nil, toServiceError(err)
 	}
 
 	msgUnixTime, err := time.FromFarcasterTime(int64(message.Data.Timestamp))
@@ -37,7 +73,7 @@ func (s *hubRPCServer) SubmitMessage(ctx context.Context, message *protos.Messag
 		log.Error("Couldn't convert FC time to unix time |", "Error", err)
 	}
 	log.Debug("Received a message from gRPC! |",
-		"Text", message.Data.GetCastAddBody().Text,
+		"Type", message.Data.Type,
 		"Hash", utils.BytesToHex(message.Hash),
 		"Signer", utils.BytesToHex(message.Signer),
 		"Signature", utils.BytesToHex(message.Signature),
@@ -60,21 +96,190 @@ func (s *hubRPCServer) SubmitMessage(ctx context.Context, message *protos.Messag
 		Timestamp: uint32(contactInfoTime),
 	}
 
-	s.netw.Publish(&msg)
+	err = s.netw.Publish(&msg)
+	if err != nil {
+		submitMessageOutcomes.WithLabelValues("publish_failed").Inc()
+		s.ll.Error("Couldn't publish the message from gRPC! |", "Error", err, "Hash", utils.BytesToHex(message.Hash))
+		return nil, status.Error(codes.Unavailable, fmt.Sprintf("unavailable.network_failure: %s", err))
+	}
 
+	submitMessageOutcomes.WithLabelValues("published").Inc()
 	return message, nil
 }
 
-func newServer(netw Network, ll log.Logger) *hubRPCServer {
+func (s *hubRPCServer) ValidateMessage(ctx context.Context, message *protos.Message) (*protos.ValidationResponse, error) {
+	err := validation.ValidateMessageForNetwork(message, s.network)
+	if err != nil {
+		s.ll.Debug("Message is invalid! |", "Error", err, "Hash", utils.BytesToHex(message.Hash))
+	}
+
+	return &protos.ValidationResponse{
+		Valid:   err == nil,
+		Message: message,
+	}, nil
+}
+
+func (s *hubRPCServer) GetCast(ctx context.Context, castId *protos.CastId) (*protos.Message, error) {
+	m, err := s.store.GetCast(castId.Fid, castId.Hash)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return m, nil
+}
+
+func (s *hubRPCServer) GetCastsByFid(ctx context.Context, req *protos.FidRequest) (*protos.MessagesResponse, error) {
+	return messagesResponse(s.store.GetCastsByFid(req.Fid, store.PageOptions{
+		PageSize:  req.GetPageSize(),
+		PageToken: req.GetPageToken(),
+		Reverse:   req.GetReverse(),
+	}))
+}
+
+func (s *hubRPCServer) GetReactionsByCast(ctx context.Context, req *protos.ReactionsByTargetRequest) (*protos.MessagesResponse, error) {
+	target := &protos.ReactionBody{}
+	if req.GetTargetCastId() != nil {
+		target.Target = &protos.ReactionBody_TargetCastId{TargetCastId: req.GetTargetCastId()}
+	} else {
+		target.Target = &protos.ReactionBody_TargetUrl{TargetUrl: req.GetTargetUrl()}
+	}
+
+	return messagesResponse(s.store.GetReactionsByTarget(target, req.ReactionType, store.PageOptions{
+		PageSize:  req.GetPageSize(),
+		PageToken: req.GetPageToken(),
+		Reverse:   req.GetReverse(),
+	}))
+}
+
+func (s *hubRPCServer) GetLinksByFid(ctx context.Context, req *protos.LinksByFidRequest) (*protos.MessagesResponse, error) {
+	return messagesResponse(s.store.GetLinksByFid(req.Fid, req.GetLinkType(), store.PageOptions{
+		PageSize:  req.GetPageSize(),
+		PageToken: req.GetPageToken(),
+		Reverse:   req.GetReverse(),
+	}))
+}
+
+func (s *hubRPCServer) GetUserDataByFid(ctx context.Context, req *protos.FidRequest) (*protos.MessagesResponse, error) {
+	return messagesResponse(s.store.GetUserDataByFid(req.Fid, store.PageOptions{
+		PageSize:  req.GetPageSize(),
+		PageToken: req.GetPageToken(),
+		Reverse:   req.GetReverse(),
+	}))
+}
+
+// Streams the messages received on gossip as merge events. Every subscriber gets its own buffer where the oldest
+// events are dropped if the client is too slow, so it can't hold back the hub. Past events aren't kept: from_id is
+// ignored.
+func (s *hubRPCServer) Subscribe(req *protos.SubscribeRequest, stream protos.HubService_SubscribeServer) error {
+	if s.dispatcher == nil {
+		return status.Error(codes.Unimplemented, "the hub isn't connected to the network")
+	}
+
+	if len(req.EventTypes) > 0 && !slices.Contains(req.EventTypes, protos.HubEventType_HUB_EVENT_TYPE_MERGE_MESSAGE) {
+		return status.Error(codes.InvalidArgument, "bad_request.invalid_param: only HUB_EVENT_TYPE_MERGE_MESSAGE events are supported")
+	}
+
+	name := "grpc"
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		name = fmt.Sprintf("grpc %s", p.Addr)
+	}
+	sub := s.dispatcher.Subscribe(name, SubscribeOptions{
+		BufferSize:   s.bufferSize,
+		Backpressure: BackpressureDropOldest,
+		VerifiedOnly: true,
+	})
+	defer s.dispatcher.Unsubscribe(sub)
+
+	s.ll.Info("New subscriber to the events! |", "Name", name, "MessageTypes", req.MessageTypes, "Fids", req.Fids)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			s.ll.Info("Subscriber left! |", "Name", name, "Dropped", sub.Dropped())
+			return nil
+		case msgB, ok := <-sub.Messages:
+			if !ok {
+				return status.Error(codes.Unavailable, "unavailable: the hub is shutting down")
+			}
+
+			msgs, _ := handlers.ExtractMessages(msgB)
+			for _, m := range msgs {
+				if len(req.MessageTypes) > 0 && !slices.Contains(req.MessageTypes, m.Data.Type) {
+					continue
+				}
+				if len(req.Fids) > 0 && !slices.Contains(req.Fids, m.Data.Fid) {
+					continue
+				}
+
+				err := stream.Send(&protos.HubEvent{
+					Type: protos.HubEventType_HUB_EVENT_TYPE_MERGE_MESSAGE,
+					Id:   s.eventIds.next(),
+					Body: &protos.HubEvent_MergeMessageBody{
+						MergeMessageBody: &protos.MergeMessageBody{Message: m},
+					},
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// Makes increasing event IDs following Hubble's format: a timestamp followed by a sequence number.
+type eventIdGenerator struct {
+	mu         sync.Mutex
+	lastMillis int64
+	seq        int64
+}
+
+func (g *eventIdGenerator) next() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	nowMillis := gotime.Now().UnixMilli()
+	if nowMillis > g.lastMillis {
+		g.lastMillis, g.seq = nowMillis, 0
+	} else {
+		g.seq++
+		// the sequence is full for this millisecond: borrow the next one
+		if g.seq >= 1<<12 {
+			g.lastMillis, g.seq = g.lastMillis+1, 0
+		}
+	}
+
+	eventId, err := time.MakeEventId(g.lastMillis, g.seq)
+	if err != nil {
+		return 0
+	}
+	return uint64(eventId)
+}
+
+func messagesResponse(messages []*protos.Message, nextPageToken []byte, err error) (*protos.MessagesResponse, error) {
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &protos.MessagesResponse{
+		Messages:      messages,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func newServer(netw *Network, ll log.Logger, hub config.HubParams, msgStore *store.Store, dispatcher *Dispatcher) *hubRPCServer {
 	s := &hubRPCServer{
-		netw:      netw,
-		ll:        ll,
-		rpcServer: make(map[string][]*protos.HubServiceServer),
+		netw:       netw,
+		ll:         ll,
+		network:    protos.FarcasterNetwork(hub.Network),
+		store:      msgStore,
+		dispatcher: dispatcher,
+		bufferSize: hub.BufferSize,
+		rpcServer:  make(map[string][]*protos.HubServiceServer),
 	}
 	return s
 }
 
-func Start(wg *sync.WaitGroup, stopCh <-chan struct{}, netw Network) {
+func Start(wg *sync.WaitGroup, stopCh <-chan struct{}, netw *Network, msgStore *store.Store, dispatcher *Dispatcher) {
 	defer wg.Done()
 
 	ll := log.New(os.Stderr)
@@ -85,7 +290,7 @@ func Start(wg *sync.WaitGroup, stopCh <-chan struct{}, netw Network) {
 		ll.Error("Couln't open config.toml, using default ports! |", "Err", err)
 	}
 
-	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", conf.Hub.RpcPort))
+	lis, err := net.Listen("tcp", net.JoinHostPort(conf.Hub.RpcHost, strconv.FormatUint(uint64(conf.Hub.RpcPort), 10)))
 	if err != nil {
 		ll.Fatal("Can't start the listnener! |", "Err", err)
 	}
@@ -93,7 +298,7 @@ func Start(wg *sync.WaitGroup, stopCh <-chan struct{}, netw Network) {
 	ll.Info("Started the GRPC server! |", "Port", conf.Hub.RpcPort)
 
 	grpcServer := grpc.NewServer()
-	protos.RegisterHubServiceServer(grpcServer, newServer(netw, *ll))
+	protos.RegisterHubServiceServer(grpcServer, newServer(netw, *ll, conf.Hub, msgStore, dispatcher))
 	reflection.Register(grpcServer)
 	go func() {
 		if err := grpcServer.Serve(lis); err != nil {
@@ -1,10 +1,26 @@
 package main
 
 import (
+	"context"
+	"crypto/ed25519"
+	"path/filepath"
 	"sync"
 	"testing"
 
 	"time"
+
+	"github.com/noctisatrae/farseer/config"
+	protos "github.com/noctisatrae/farseer/protos"
+	"github.com/noctisatrae/farseer/store"
+	FcTime "github.com/noctisatrae/farseer/time"
+	"github.com/noctisatrae/farseer/validation"
+
+	"github.com/charmbracelet/log"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestGracefulShutdown(t *testing.T) {
@@ -13,7 +29,7 @@ func TestGracefulShutdown(t *testing.T) {
 
 	// Start the gRPC server in a separate goroutine
 	wg.Add(1)
-	go Start(&wg, stopCh, Network{})
+	go Start(&wg, stopCh, &Network{}, nil, nil)
 
 	time.Sleep(time.Second)
 
@@ -22,3 +38,189 @@ func TestGracefulShutdown(t *testing.T) {
 	// Wait for the server to stop
 	wg.Wait()
 }
+
+var mainnetHub = config.HubParams{Network: 1, BufferSize: 16}
+
+func signedMessage(t *testing.T, data *protos.MessageData) *protos.Message {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	dataBytes, err := proto.Marshal(data)
+	assert.NoError(t, err)
+	hash := validation.Hash(dataBytes)
+
+	return &protos.Message{
+		Data:            data,
+		Hash:            hash,
+		HashScheme:      protos.HashScheme_HASH_SCHEME_BLAKE3,
+		Signature:       ed25519.Sign(priv, hash),
+		SignatureScheme: protos.SignatureScheme_SIGNATURE_SCHEME_ED25519,
+		Signer:          pub,
+	}
+}
+
+// Are invalid messages refused before being published?
+func TestSubmitInvalidMessage(t *testing.T) {
+	s := newServer(&Network{}, *log.Default(), mainnetHub, nil, nil)
+
+	fcTime, err := FcTime.GetFarcasterTime()
+	assert.NoError(t, err)
+
+	invalidMessages := map[string]*protos.Message{
+		"no data": {},
+		"testnet": signedMessage(t, &protos.MessageData{
+			Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD, Fid: 10626, Timestamp: uint32(fcTime),
+			Network: protos.FarcasterNetwork_FARCASTER_NETWORK_TESTNET,
+			Body:    &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: "gm"}},
+		}),
+		"future": signedMessage(t, &protos.MessageData{
+			Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD, Fid: 10626, Timestamp: uint32(fcTime + 3600),
+			Network: protos.FarcasterNetwork_FARCASTER_NETWORK_MAINNET,
+			Body:    &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: "gm"}},
+		}),
+		"wrong body": signedMessage(t, &protos.MessageData{
+			Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD, Fid: 10626, Timestamp: uint32(fcTime),
+			Network: protos.FarcasterNetwork_FARCASTER_NETWORK_MAINNET,
+			Body:    &protos.MessageData_CastRemoveBody{CastRemoveBody: &protos.CastRemoveBody{TargetHash: []byte{1}}},
+		}),
+	}
+
+	for name, m := range invalidMessages {
+		_, err := s.SubmitMessage(context.Background(), m)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), name)
+
+		res, err := s.ValidateMessage(context.Background(), m)
+		assert.NoError(t, err, name)
+		assert.False(t, res.Valid, name)
+	}
+
+	valid := signedMessage(t, &protos.MessageData{
+		Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD, Fid: 10626, Timestamp: uint32(fcTime),
+		Network: protos.FarcasterNetwork_FARCASTER_NETWORK_MAINNET,
+		Body:    &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: "gm"}},
+	})
+	res, err := s.ValidateMessage(context.Background(), valid)
+	assert.NoError(t, err)
+	assert.True(t, res.Valid)
+}
+
+// Are the messages of the store served with Hubble's pagination?
+func TestReadRPCs(t *testing.T) {
+	msgStore, err := store.Open(filepath.Join(t.TempDir(), "farseer.db"))
+	assert.NoError(t, err)
+	defer msgStore.Close()
+
+	s := newServer(&Network{}, *log.Default(), mainnetHub, msgStore, nil)
+
+	for i := 0; i < 3; i++ {
+		m := signedMessage(t, &protos.MessageData{
+			Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD, Fid: 10626, Timestamp: uint32(100 + i),
+			Network: protos.FarcasterNetwork_FARCASTER_NETWORK_MAINNET,
+			Body:    &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: "gm"}},
+		})
+		assert.NoError(t, msgStore.Merge(m))
+	}
+
+	pageSize := uint32(2)
+	res, err := s.GetCastsByFid(context.Background(), &protos.FidRequest{Fid: 10626, PageSize: &pageSize})
+	assert.NoError(t, err)
+	assert.Len(t, res.Messages, 2)
+
+	res, err = s.GetCastsByFid(context.Background(), &protos.FidRequest{Fid: 10626, PageSize: &pageSize, PageToken: res.NextPageToken})
+	assert.NoError(t, err)
+	assert.Len(t, res.Messages, 1)
+	assert.Nil(t, res.NextPageToken)
+
+	cast, err := s.GetCast(context.Background(), &protos.CastId{Fid: 10626, Hash: res.Messages[0].Hash})
+	assert.NoError(t, err)
+	assert.Equal(t, res.Messages[0].Hash, cast.Hash)
+
+	_, err = s.GetCast(context.Background(), &protos.CastId{Fid: 10626, Hash: []byte{1}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+type fakeSubscribeStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *protos.HubEvent
+}
+
+func (f *fakeSubscribeStream) Context() context.Context { return f.ctx }
+
+func (f *fakeSubscribeStream) Send(event *protos.HubEvent) error {
+	f.events <- event
+	return nil
+}
+
+// Are the messages of the dispatcher streamed to the subscribers with their filters?
+func TestSubscribe(t *testing.T) {
+	log.SetLevel(log.DebugLevel)
+
+	dispatcher := NewDispatcher(*log.Default())
+	s := newServer(&Network{}, *log.Default(), mainnetHub, nil, dispatcher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeSubscribeStream{ctx: ctx, events: make(chan *protos.HubEvent, 10)}
+
+	done := make(chan error)
+	go func() {
+		done <- s.Subscribe(&protos.SubscribeRequest{
+			MessageTypes: []protos.MessageType{protos.MessageType_MESSAGE_TYPE_CAST_ADD},
+			Fids:         []uint64{10626},
+		}, stream)
+	}()
+
+	// wait for the stream to be subscribed
+	assert.Eventually(t, func() bool {
+		dispatcher.mu.RLock()
+		defer dispatcher.mu.RUnlock()
+		return len(dispatcher.subscribers) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cast := func(fid uint64, text string) *protos.Message {
+		return signedMessage(t, &protos.MessageData{
+			Type: protos.MessageType_MESSAGE_TYPE_CAST_ADD, Fid: fid, Timestamp: 100,
+			Network: protos.FarcasterNetwork_FARCASTER_NETWORK_MAINNET,
+			Body:    &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: text}},
+		})
+	}
+	link := signedMessage(t, &protos.MessageData{
+		Type: protos.MessageType_MESSAGE_TYPE_LINK_ADD, Fid: 10626, Timestamp: 100,
+		Network: protos.FarcasterNetwork_FARCASTER_NETWORK_MAINNET,
+		Body:    &protos.MessageData_LinkBody{LinkBody: &protos.LinkBody{Type: "follow", Target: &protos.LinkBody_TargetFid{TargetFid: 2}}},
+	})
+	forged := cast(10626, "forged")
+	forged.Signature[0] ^= 0xff
+
+	dispatcher.Dispatch(&protos.GossipMessage{Content: &protos.GossipMessage_MessageBundle{MessageBundle: &protos.MessageBundle{
+		Messages: []*protos.Message{cast(10627, "other"), link, forged, cast(10626, "gm")},
+	}}})
+
+	event := <-stream.events
+	assert.Equal(t, protos.HubEventType_HUB_EVENT_TYPE_MERGE_MESSAGE, event.Type)
+	assert.Equal(t, "gm", event.GetMergeMessageBody().GetMessage().GetData().GetCastAddBody().GetText())
+	assert.NotZero(t, event.Id)
+	assert.Len(t, stream.events, 0)
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	// the subscriber is gone once the client leaves
+	dispatcher.mu.RLock()
+	assert.Len(t, dispatcher.subscribers, 0)
+	dispatcher.mu.RUnlock()
+
+	err := s.Subscribe(&protos.SubscribeRequest{EventTypes: []protos.HubEventType{protos.HubEventType_HUB_EVENT_TYPE_NONE}}, stream)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Are event IDs increasing, even within the same millisecond?
+func TestEventIds(t *testing.T) {
+	var g eventIdGenerator
+	last := uint64(0)
+	for i := 0; i < 10000; i++ {
+		id := g.next()
+		assert.Greater(t, id, last)
+		last = id
+	}
+}
@@ -0,0 +1,456 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/noctisatrae/farseer/handlers"
+	protos "github.com/noctisatrae/farseer/protos"
+	"github.com/noctisatrae/farseer/utils"
+
+	"github.com/charmbracelet/log"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+	// The most messages MergeMessages merges in a single transaction.
+	MergeBatchSize = 256
+)
+
+var ErrNotFound = errors.New("not_found: message not found")
+
+var (
+	// primary key => serialized message
+	messagesBucket = []byte("messages")
+	// fid|hash => primary key of a cast
+	castsByHashBucket = []byte("casts_by_hash")
+	// target|timestamp|hash => primary key of a reaction
+	reactionsByTargetBucket = []byte("reactions_by_target")
+	// unique key of an add/remove pair => timestamp|hash|flag of the latest operation that was merged
+	crdtBucket = []byte("crdt")
+)
+
+const (
+	opAdd    byte = 0
+	opRemove byte = 1
+)
+
+// An embedded store of the messages seen by the hub. Messages are kept under a primary key made of
+// fid|postfix|timestamp|hash, the postfix being the type of the add message, so the messages of a user are sorted
+// by time. Removes aren't stored: they delete the add they target & are remembered so a late add can't come back.
+type Store struct {
+	db *bolt.DB
+}
+
+// Where to start reading & how many messages to return.
+type PageOptions struct {
+	PageSize  uint32
+	PageToken []byte
+	Reverse   bool
+}
+
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{messagesBucket, castsByHashBucket, reactionsByTargetBucket, crdtBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Merges the messages of the gossip messages received on the channel until it's closed. The messages waiting on the
+// channel are merged together in a single transaction so a slow disk doesn't sync once per message.
+func (s *Store) MergeMessages(messages chan *protos.GossipMessage, ll log.Logger) {
+	for msgB := range messages {
+		batch, _ := handlers.ExtractMessages(msgB)
+		open := true
+	drain:
+		for open && len(batch) < MergeBatchSize {
+			select {
+			case msgB, ok := <-messages:
+				if !ok {
+					open = false
+					break
+				}
+				msgs, _ := handlers.ExtractMessages(msgB)
+				batch = append(batch, msgs...)
+			default:
+				break drain
+			}
+		}
+
+		s.mergeBatch(batch, ll)
+		if !open {
+			return
+		}
+	}
+}
+
+// Merges the messages in a single transaction, or one by one when the transaction fails so a bad message doesn't
+// drop the others.
+func (s *Store) mergeBatch(batch []*protos.Message, ll log.Logger) {
+	if len(batch) == 0 {
+		return
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, m := range batch {
+			if err := merge(tx, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return
+	}
+
+	for _, m := range batch {
+		if err := s.Merge(m); err != nil {
+			ll.Error("Couldn't merge the message into the store! |", "Error", err, "Hash", utils.BytesToHex(m.Hash))
+		}
+	}
+}
+
+// Merges a message into the store. Messages of a type that can't be queried are ignored.
+func (s *Store) Merge(m *protos.Message) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return merge(tx, m)
+	})
+}
+
+func merge(tx *bolt.Tx, m *protos.Message) error {
+	data := m.GetData()
+	if data == nil {
+		return errors.New("bad_request.validation_failure: data is missing")
+	}
+
+	switch data.Type {
+	case protos.MessageType_MESSAGE_TYPE_CAST_ADD:
+		return mergeCastAdd(tx, m)
+	case protos.MessageType_MESSAGE_TYPE_CAST_REMOVE:
+		return mergeCastRemove(tx, m)
+	case protos.MessageType_MESSAGE_TYPE_REACTION_ADD, protos.MessageType_MESSAGE_TYPE_REACTION_REMOVE:
+		body := data.GetReactionBody()
+		target := reactionTargetKey(body)
+		if target == nil {
+			return nil
+		}
+		uniqueKey := concat(fidKey(data.Fid), []byte{byte(protos.MessageType_MESSAGE_TYPE_REACTION_ADD), byte(body.GetType())}, target)
+		return mergeAddRemove(tx, m, uniqueKey, data.Type == protos.MessageType_MESSAGE_TYPE_REACTION_REMOVE, protos.MessageType_MESSAGE_TYPE_REACTION_ADD, target)
+	case protos.MessageType_MESSAGE_TYPE_LINK_ADD, protos.MessageType_MESSAGE_TYPE_LINK_REMOVE:
+		body := data.GetLinkBody()
+		uniqueKey := concat(fidKey(data.Fid), []byte{byte(protos.MessageType_MESSAGE_TYPE_LINK_ADD)}, []byte(body.GetType()), []byte{0}, fidKey(body.GetTargetFid()))
+		return mergeAddRemove(tx, m, uniqueKey, data.Type == protos.MessageType_MESSAGE_TYPE_LINK_REMOVE, protos.MessageType_MESSAGE_TYPE_LINK_ADD, nil)
+	case protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD:
+		uniqueKey := concat(fidKey(data.Fid), []byte{byte(protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD), byte(data.GetUserDataBody().GetType())})
+		return mergeAddRemove(tx, m, uniqueKey, false, protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD, nil)
+	default:
+		return nil
+	}
+}
+
+func mergeCastAdd(tx *bolt.Tx, m *protos.Message) error {
+	fid := m.Data.Fid
+	if tx.Bucket(crdtBucket).Get(concat(fidKey(fid), []byte{byte(protos.MessageType_MESSAGE_TYPE_CAST_ADD)}, m.Hash)) != nil {
+		// the cast was already removed
+		return nil
+	}
+
+	key := primaryKey(fid, protos.MessageType_MESSAGE_TYPE_CAST_ADD, tsHash(m))
+	if err := putMessage(tx, key, m); err != nil {
+		return err
+	}
+
+	return tx.Bucket(castsByHashBucket).Put(concat(fidKey(fid), m.Hash), key)
+}
+
+// Removes always win over adds for casts.
+func mergeCastRemove(tx *bolt.Tx, m *protos.Message) error {
+	fid := m.Data.Fid
+	targetHash := m.Data.GetCastRemoveBody().GetTargetHash()
+
+	byHashKey := concat(fidKey(fid), targetHash)
+	if key := tx.Bucket(castsByHashBucket).Get(byHashKey); key != nil {
+		if err := tx.Bucket(messagesBucket).Delete(key); err != nil {
+			return err
+		}
+		if err := tx.Bucket(castsByHashBucket).Delete(byHashKey); err != nil {
+			return err
+		}
+	}
+
+	return tx.Bucket(crdtBucket).Put(concat(fidKey(fid), []byte{byte(protos.MessageType_MESSAGE_TYPE_CAST_ADD)}, targetHash), concat(tsHash(m), []byte{opRemove}))
+}
+
+// Merges a message of a set where only the latest operation for a unique key is kept: if the message wins over the
+// last one, the previous add is deleted & the message is stored when it's an add.
+func mergeAddRemove(tx *bolt.Tx, m *protos.Message, uniqueKey []byte, isRemove bool, addType protos.MessageType, reactionTarget []byte) error {
+	crdt := tx.Bucket(crdtBucket)
+	newTsHash := tsHash(m)
+
+	if last := crdt.Get(uniqueKey); last != nil {
+		lastTsHash, lastIsRemove := last[:len(last)-1], last[len(last)-1] == opRemove
+		if !wins(newTsHash, isRemove, lastTsHash, lastIsRemove) {
+			return nil
+		}
+
+		if !lastIsRemove {
+			if err := tx.Bucket(messagesBucket).Delete(primaryKey(m.Data.Fid, addType, lastTsHash)); err != nil {
+				return err
+			}
+			if reactionTarget != nil {
+				if err := tx.Bucket(reactionsByTargetBucket).Delete(concat(reactionTarget, lastTsHash)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	op := opAdd
+	if isRemove {
+		op = opRemove
+	} else {
+		key := primaryKey(m.Data.Fid, addType, newTsHash)
+		if err := putMessage(tx, key, m); err != nil {
+			return err
+		}
+		if reactionTarget != nil {
+			if err := tx.Bucket(reactionsByTargetBucket).Put(concat(reactionTarget, newTsHash), key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return crdt.Put(uniqueKey, concat(newTsHash, []byte{op}))
+}
+
+// Farcaster's conflict resolution: the latest timestamp wins, then removes win over adds, then the highest hash.
+func wins(aTsHash []byte, aIsRemove bool, bTsHash []byte, bIsRemove bool) bool {
+	if cmp := bytes.Compare(aTsHash[:4], bTsHash[:4]); cmp != 0 {
+		return cmp > 0
+	}
+	if aIsRemove != bIsRemove {
+		return aIsRemove
+	}
+	return bytes.Compare(aTsHash[4:], bTsHash[4:]) > 0
+}
+
+func putMessage(tx *bolt.Tx, key []byte, m *protos.Message) error {
+	mBytes, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(messagesBucket).Put(key, mBytes)
+}
+
+func (s *Store) GetCast(fid uint64, hash []byte) (*protos.Message, error) {
+	var m *protos.Message
+	err := s.db.View(func(tx *bolt.Tx) error {
+		key := tx.Bucket(castsByHashBucket).Get(concat(fidKey(fid), hash))
+		if key == nil {
+			return ErrNotFound
+		}
+
+		var err error
+		m, err = getMessage(tx, key)
+		return err
+	})
+
+	return m, err
+}
+
+func (s *Store) GetCastsByFid(fid uint64, page PageOptions) ([]*protos.Message, []byte, error) {
+	return s.scanMessages(primaryPrefix(fid, protos.MessageType_MESSAGE_TYPE_CAST_ADD), page, nil)
+}
+
+// Gets the reactions to the target of a reaction body, a cast or an URL. A nil reactionType returns every type.
+func (s *Store) GetReactionsByTarget(target *protos.ReactionBody, reactionType *protos.ReactionType, page PageOptions) ([]*protos.Message, []byte, error) {
+	targetKey := reactionTargetKey(target)
+	if targetKey == nil {
+		return nil, nil, errors.New("bad_request.validation_failure: target is missing")
+	}
+
+	return s.scan(reactionsByTargetBucket, targetKey, page, func(tx *bolt.Tx, key []byte) (*protos.Message, error) {
+		m, err := getMessage(tx, key)
+		if err != nil || (reactionType != nil && m.GetData().GetReactionBody().GetType() != *reactionType) {
+			return nil, err
+		}
+		return m, nil
+	})
+}
+
+// Gets the links of a user. An empty linkType returns every type.
+func (s *Store) GetLinksByFid(fid uint64, linkType string, page PageOptions) ([]*protos.Message, []byte, error) {
+	return s.scanMessages(primaryPrefix(fid, protos.MessageType_MESSAGE_TYPE_LINK_ADD), page, func(m *protos.Message) bool {
+		return linkType == "" || m.GetData().GetLinkBody().GetType() == linkType
+	})
+}
+
+func (s *Store) GetUserDataByFid(fid uint64, page PageOptions) ([]*protos.Message, []byte, error) {
+	return s.scanMessages(primaryPrefix(fid, protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD), page, nil)
+}
+
+func (s *Store) scanMessages(prefix []byte, page PageOptions, keep func(m *protos.Message) bool) ([]*protos.Message, []byte, error) {
+	return s.scan(messagesBucket, prefix, page, func(tx *bolt.Tx, mBytes []byte) (*protos.Message, error) {
+		m := new(protos.Message)
+		if err := proto.Unmarshal(mBytes, m); err != nil {
+			return nil, err
+		}
+		if keep != nil && !keep(m) {
+			return nil, nil
+		}
+		return m, nil
+	})
+}
+
+// Reads a page of the keys of a bucket starting with prefix. The value of every key is turned into a message by
+// resolve, which can return nil to skip it. The page token is the last key that was read.
+func (s *Store) scan(bucket []byte, prefix []byte, page PageOptions, resolve func(tx *bolt.Tx, value []byte) (*protos.Message, error)) ([]*protos.Message, []byte, error) {
+	pageSize := int(page.PageSize)
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	} else if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	messages := []*protos.Message{}
+	var nextPageToken []byte
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+
+		var k, v, lastKey []byte
+		if page.PageToken != nil {
+			if !bytes.HasPrefix(page.PageToken, prefix) {
+				return errors.New("bad_request.invalid_param: invalid page token")
+			}
+			k, v = c.Seek(page.PageToken)
+			if page.Reverse {
+				k, v = c.Prev()
+			} else if bytes.Equal(k, page.PageToken) {
+				k, v = c.Next()
+			}
+		} else if page.Reverse {
+			k, v = c.Seek(prefixEnd(prefix))
+			if k == nil {
+				k, v = c.Last()
+			} else {
+				k, v = c.Prev()
+			}
+		} else {
+			k, v = c.Seek(prefix)
+		}
+
+		for ; k != nil && bytes.HasPrefix(k, prefix); k, v = step(c, page.Reverse) {
+			if len(messages) == pageSize {
+				nextPageToken = lastKey
+				return nil
+			}
+
+			m, err := resolve(tx, v)
+			if err != nil {
+				return err
+			}
+			lastKey = append([]byte{}, k...)
+			if m != nil {
+				messages = append(messages, m)
+			}
+		}
+
+		return nil
+	})
+
+	return messages, nextPageToken, err
+}
+
+func step(c *bolt.Cursor, reverse bool) ([]byte, []byte) {
+	if reverse {
+		return c.Prev()
+	}
+	return c.Next()
+}
+
+// The first key that comes after every key starting with prefix.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+func getMessage(tx *bolt.Tx, key []byte) (*protos.Message, error) {
+	mBytes := tx.Bucket(messagesBucket).Get(key)
+	if mBytes == nil {
+		return nil, ErrNotFound
+	}
+
+	m := new(protos.Message)
+	err := proto.Unmarshal(mBytes, m)
+	return m, err
+}
+
+func concat(parts ...[]byte) []byte {
+	key := []byte{}
+	for _, part := range parts {
+		key = append(key, part...)
+	}
+	return key
+}
+
+func fidKey(fid uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, fid)
+}
+
+func tsHash(m *protos.Message) []byte {
+	return append(binary.BigEndian.AppendUint32(nil, m.Data.Timestamp), m.Hash...)
+}
+
+func primaryPrefix(fid uint64, postfix protos.MessageType) []byte {
+	return append(fidKey(fid), byte(postfix))
+}
+
+func primaryKey(fid uint64, postfix protos.MessageType, tsHash []byte) []byte {
+	return concat(primaryPrefix(fid, postfix), tsHash)
+}
+
+// The key of the target of a reaction: a kind byte & the length of the target so no target is the prefix of another.
+func reactionTargetKey(body *protos.ReactionBody) []byte {
+	var kind byte
+	var target []byte
+	if castId := body.GetTargetCastId(); castId != nil {
+		kind, target = 'c', concat(fidKey(castId.Fid), castId.Hash)
+	} else if url := body.GetTargetUrl(); url != "" {
+		kind, target = 'u', []byte(url)
+	} else {
+		return nil
+	}
+
+	return concat([]byte{kind}, binary.BigEndian.AppendUint16(nil, uint16(len(target))), target)
+}
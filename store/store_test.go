@@ -0,0 +1,175 @@
+package store_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	protos "github.com/noctisatrae/farseer/protos"
+	"github.com/noctisatrae/farseer/store"
+
+	"github.com/charmbracelet/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func openStore(t *testing.T) *store.Store {
+	s, err := store.Open(filepath.Join(t.TempDir(), "farseer.db"))
+	assert.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func message(msgType protos.MessageType, fid uint64, timestamp uint32, hash byte, data *protos.MessageData) *protos.Message {
+	data.Type = msgType
+	data.Fid = fid
+	data.Timestamp = timestamp
+	data.Network = protos.FarcasterNetwork_FARCASTER_NETWORK_MAINNET
+	return &protos.Message{Data: data, Hash: []byte{hash, hash, hash}}
+}
+
+func castAdd(fid uint64, timestamp uint32, hash byte, text string) *protos.Message {
+	return message(protos.MessageType_MESSAGE_TYPE_CAST_ADD, fid, timestamp, hash, &protos.MessageData{
+		Body: &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: text}},
+	})
+}
+
+func reaction(msgType protos.MessageType, fid uint64, timestamp uint32, hash byte, target *protos.CastId) *protos.Message {
+	return message(msgType, fid, timestamp, hash, &protos.MessageData{
+		Body: &protos.MessageData_ReactionBody{ReactionBody: &protos.ReactionBody{
+			Type:   protos.ReactionType_REACTION_TYPE_LIKE,
+			Target: &protos.ReactionBody_TargetCastId{TargetCastId: target},
+		}},
+	})
+}
+
+func link(msgType protos.MessageType, fid uint64, timestamp uint32, hash byte, targetFid uint64) *protos.Message {
+	return message(msgType, fid, timestamp, hash, &protos.MessageData{
+		Body: &protos.MessageData_LinkBody{LinkBody: &protos.LinkBody{
+			Type:   "follow",
+			Target: &protos.LinkBody_TargetFid{TargetFid: targetFid},
+		}},
+	})
+}
+
+func texts(msgs []*protos.Message) []string {
+	res := []string{}
+	for _, m := range msgs {
+		res = append(res, m.Data.GetCastAddBody().GetText())
+	}
+	return res
+}
+
+func TestCasts(t *testing.T) {
+	s := openStore(t)
+
+	for i, text := range []string{"one", "two", "three"} {
+		assert.NoError(t, s.Merge(castAdd(10626, uint32(100+i), byte(i+1), text)))
+	}
+	// other users' casts are not mixed in
+	assert.NoError(t, s.Merge(castAdd(10627, 100, 9, "other")))
+
+	cast, err := s.GetCast(10626, []byte{2, 2, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "two", cast.Data.GetCastAddBody().GetText())
+
+	_, err = s.GetCast(10627, []byte{2, 2, 2})
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	page, token, err := s.GetCastsByFid(10626, store.PageOptions{PageSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, texts(page))
+	assert.NotNil(t, token)
+
+	page, token, err = s.GetCastsByFid(10626, store.PageOptions{PageSize: 2, PageToken: token})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"three"}, texts(page))
+	assert.Nil(t, token)
+
+	page, _, err = s.GetCastsByFid(10626, store.PageOptions{Reverse: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"three", "two", "one"}, texts(page))
+
+	// a removed cast is gone & doesn't come back when its add is received again
+	remove := message(protos.MessageType_MESSAGE_TYPE_CAST_REMOVE, 10626, 200, 7, &protos.MessageData{
+		Body: &protos.MessageData_CastRemoveBody{CastRemoveBody: &protos.CastRemoveBody{TargetHash: []byte{2, 2, 2}}},
+	})
+	assert.NoError(t, s.Merge(remove))
+	assert.NoError(t, s.Merge(castAdd(10626, 101, 2, "two")))
+
+	_, err = s.GetCast(10626, []byte{2, 2, 2})
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestReactions(t *testing.T) {
+	s := openStore(t)
+	target := &protos.CastId{Fid: 1, Hash: []byte{4, 2}}
+	otherTarget := &protos.CastId{Fid: 1, Hash: []byte{4, 2, 0}}
+
+	assert.NoError(t, s.Merge(reaction(protos.MessageType_MESSAGE_TYPE_REACTION_ADD, 10626, 100, 1, target)))
+	assert.NoError(t, s.Merge(reaction(protos.MessageType_MESSAGE_TYPE_REACTION_ADD, 10627, 100, 2, target)))
+	assert.NoError(t, s.Merge(reaction(protos.MessageType_MESSAGE_TYPE_REACTION_ADD, 10628, 100, 3, otherTarget)))
+
+	like := protos.ReactionType_REACTION_TYPE_LIKE
+	reactions, _, err := s.GetReactionsByTarget(&protos.ReactionBody{Target: &protos.ReactionBody_TargetCastId{TargetCastId: target}}, &like, store.PageOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, reactions, 2)
+
+	// only the reaction of 10626 is removed & an older add can't bring it back
+	assert.NoError(t, s.Merge(reaction(protos.MessageType_MESSAGE_TYPE_REACTION_REMOVE, 10626, 150, 4, target)))
+	assert.NoError(t, s.Merge(reaction(protos.MessageType_MESSAGE_TYPE_REACTION_ADD, 10626, 120, 5, target)))
+
+	reactions, _, err = s.GetReactionsByTarget(&protos.ReactionBody{Target: &protos.ReactionBody_TargetCastId{TargetCastId: target}}, nil, store.PageOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, reactions, 1)
+	assert.Equal(t, uint64(10627), reactions[0].Data.Fid)
+}
+
+func TestLinksAndUserData(t *testing.T) {
+	s := openStore(t)
+
+	assert.NoError(t, s.Merge(link(protos.MessageType_MESSAGE_TYPE_LINK_ADD, 10626, 100, 1, 2)))
+	assert.NoError(t, s.Merge(link(protos.MessageType_MESSAGE_TYPE_LINK_ADD, 10626, 100, 2, 3)))
+	assert.NoError(t, s.Merge(link(protos.MessageType_MESSAGE_TYPE_LINK_REMOVE, 10626, 101, 3, 3)))
+
+	links, _, err := s.GetLinksByFid(10626, "follow", store.PageOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+	assert.Equal(t, uint64(2), links[0].Data.GetLinkBody().GetTargetFid())
+
+	links, _, err = s.GetLinksByFid(10626, "block", store.PageOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, links, 0)
+
+	// only the latest value of each type of user data is kept
+	for i, name := range []string{"noctis", "farseer"} {
+		assert.NoError(t, s.Merge(message(protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD, 10626, uint32(100+i), byte(i+1), &protos.MessageData{
+			Body: &protos.MessageData_UserDataBody{UserDataBody: &protos.UserDataBody{Type: protos.UserDataType_USER_DATA_TYPE_DISPLAY, Value: name}},
+		})))
+	}
+
+	userData, _, err := s.GetUserDataByFid(10626, store.PageOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, userData, 1)
+	assert.Equal(t, "farseer", userData[0].Data.GetUserDataBody().GetValue())
+}
+
+// Are the messages waiting on the channel all merged, even when one of them is invalid?
+func TestMergeMessages(t *testing.T) {
+	s := openStore(t)
+
+	gossip := func(m *protos.Message) *protos.GossipMessage {
+		return &protos.GossipMessage{Content: &protos.GossipMessage_Message{Message: m}}
+	}
+	messages := make(chan *protos.GossipMessage, 4)
+	messages <- gossip(castAdd(10626, 100, 1, "one"))
+	messages <- gossip(&protos.Message{Hash: []byte{9, 9, 9}})
+	messages <- &protos.GossipMessage{Content: &protos.GossipMessage_MessageBundle{MessageBundle: &protos.MessageBundle{
+		Messages: []*protos.Message{castAdd(10626, 101, 2, "two"), castAdd(10626, 102, 3, "three")},
+	}}}
+	close(messages)
+
+	s.MergeMessages(messages, *log.Default())
+
+	page, _, err := s.GetCastsByFid(10626, store.PageOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "three"}, texts(page))
+}
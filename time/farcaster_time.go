@@ -32,6 +32,18 @@ func FromFarcasterTime(timeSeconds int64) (int64, error) {
 	return timeSeconds*1000 + FARCASTER_EPOCH, nil
 }
 
+// Makes an event ID from a Unix timestamp in milliseconds & a sequence number, like Hubble does.
+func MakeEventId(timeMillis int64, seq int64) (int64, error) {
+	const SEQUENCE_BITS = 12
+	if timeMillis < FARCASTER_EPOCH {
+		return 0, errors.New("bad_request.invalid_param: time must be after Farcaster epoch (01/01/2022)")
+	}
+	if seq >= 1<<SEQUENCE_BITS {
+		return 0, errors.New("bad_request.invalid_param: sequence number too large")
+	}
+	return (timeMillis-FARCASTER_EPOCH)<<SEQUENCE_BITS | seq, nil
+}
+
 // Extracts the timestamp from an event ID.
 func ExtractEventTimestamp(eventId int64) (int64, error) {
 	binaryEventId := fmt.Sprintf("%064b", eventId)
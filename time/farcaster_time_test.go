@@ -26,3 +26,12 @@ func TestToFarcasterTime(t *testing.T) {
 
 	assert.Equal(t, int64(107778482), fTime)
 }
+
+func TestMakeEventId(t *testing.T) {
+	eventId, err := time.MakeEventId(1717237682000, 3)
+	assert.NoError(t, err)
+
+	timestamp, err := time.ExtractEventTimestamp(eventId)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1717237682000), timestamp)
+}
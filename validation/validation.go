@@ -0,0 +1,296 @@
+package validation
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	protos "github.com/noctisatrae/farseer/protos"
+	fctime "github.com/noctisatrae/farseer/time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"lukechampine.com/blake3"
+)
+
+const (
+	// Length of a message hash: BLAKE3 digests are truncated to 160 bits.
+	HashLength = 20
+	// Maximum size of the data_bytes of a message.
+	MaxDataBytesLength = 2048
+	// How far in the future the timestamp of a message can be, in seconds.
+	AllowedClockSkewSeconds = 10 * 60
+)
+
+// The errors follow the taxonomy of Hubble so they can be compared with what other hubs report.
+var (
+	ErrMissingData            = errors.New("bad_request.validation_failure: data is missing")
+	ErrDataBytesTooLong       = errors.New("bad_request.validation_failure: dataBytes > 2048 bytes")
+	ErrInvalidDataBytes       = errors.New("bad_request.validation_failure: dataBytes doesn't decode to MessageData")
+	ErrInvalidHashScheme      = errors.New("bad_request.validation_failure: invalid hashScheme")
+	ErrInvalidHash            = errors.New("bad_request.validation_failure: invalid hash")
+	ErrInvalidSignatureScheme = errors.New("bad_request.validation_failure: invalid signatureScheme")
+	ErrInvalidSigner          = errors.New("bad_request.validation_failure: signer must be 32 bytes")
+	ErrInvalidSignature       = errors.New("bad_request.validation_failure: invalid signature")
+
+	ErrMissingFid         = errors.New("bad_request.validation_failure: fid is missing")
+	ErrIncorrectNetwork   = errors.New("bad_request.validation_failure: incorrect network")
+	ErrTimestampInFuture  = errors.New("bad_request.validation_failure: message timestamp is in the future")
+	ErrInvalidBodyType    = errors.New("bad_request.validation_failure: bodyType is invalid")
+	ErrInvalidMessageType = errors.New("bad_request.validation_failure: invalid message type")
+)
+
+// Checks that the body of a message matches its type.
+var bodyTypes = map[protos.MessageType]func(data *protos.MessageData) bool{
+	protos.MessageType_MESSAGE_TYPE_CAST_ADD:                     func(data *protos.MessageData) bool { return data.GetCastAddBody() != nil },
+	protos.MessageType_MESSAGE_TYPE_CAST_REMOVE:                  func(data *protos.MessageData) bool { return data.GetCastRemoveBody() != nil },
+	protos.MessageType_MESSAGE_TYPE_REACTION_ADD:                 func(data *protos.MessageData) bool { return data.GetReactionBody() != nil },
+	protos.MessageType_MESSAGE_TYPE_REACTION_REMOVE:              func(data *protos.MessageData) bool { return data.GetReactionBody() != nil },
+	protos.MessageType_MESSAGE_TYPE_LINK_ADD:                     func(data *protos.MessageData) bool { return data.GetLinkBody() != nil },
+	protos.MessageType_MESSAGE_TYPE_LINK_REMOVE:                  func(data *protos.MessageData) bool { return data.GetLinkBody() != nil },
+	protos.MessageType_MESSAGE_TYPE_LINK_COMPACT_STATE:           func(data *protos.MessageData) bool { return data.GetLinkCompactStateBody() != nil },
+	protos.MessageType_MESSAGE_TYPE_VERIFICATION_ADD_ETH_ADDRESS: func(data *protos.MessageData) bool { return data.GetVerificationAddAddressBody() != nil },
+	protos.MessageType_MESSAGE_TYPE_VERIFICATION_REMOVE:          func(data *protos.MessageData) bool { return data.GetVerificationRemoveBody() != nil },
+	protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD:                func(data *protos.MessageData) bool { return data.GetUserDataBody() != nil },
+	protos.MessageType_MESSAGE_TYPE_USERNAME_PROOF:               func(data *protos.MessageData) bool { return data.GetUsernameProofBody() != nil },
+	protos.MessageType_MESSAGE_TYPE_FRAME_ACTION:                 func(data *protos.MessageData) bool { return data.GetFrameActionBody() != nil },
+}
+
+// Gets the bytes that were hashed by the author of the message: data_bytes when it's set, the serialized data
+// otherwise.
+func DataBytes(m *protos.Message) ([]byte, error) {
+	if m.DataBytes != nil {
+		if len(m.DataBytes) > MaxDataBytesLength {
+			return nil, ErrDataBytesTooLong
+		}
+		return m.DataBytes, nil
+	}
+
+	if m.Data == nil {
+		return nil, ErrMissingData
+	}
+
+	return proto.Marshal(m.Data)
+}
+
+// Computes the BLAKE3-160 digest of serialized MessageData.
+func Hash(dataBytes []byte) []byte {
+	hasher := blake3.New(HashLength, nil)
+	hasher.Write(dataBytes)
+	return hasher.Sum(nil)
+}
+
+// Checks that the hash of a message is the BLAKE3 digest of its data & that it was signed with Ed25519 by its
+// signer. It doesn't check that the signer belongs to the fid: this requires the onchain events.
+func ValidateMessage(m *protos.Message) error {
+	if m.Data == nil && m.DataBytes == nil {
+		return ErrMissingData
+	}
+
+	dataBytes, err := DataBytes(m)
+	if err != nil {
+		return err
+	}
+
+	if m.DataBytes != nil {
+		data := new(protos.MessageData)
+		if err := proto.Unmarshal(m.DataBytes, data); err != nil {
+			return ErrInvalidDataBytes
+		}
+		if m.Data != nil && !proto.Equal(data, m.Data) {
+			return ErrInvalidDataBytes
+		}
+	}
+
+	if m.HashScheme != protos.HashScheme_HASH_SCHEME_BLAKE3 {
+		return ErrInvalidHashScheme
+	}
+
+	if !bytes.Equal(Hash(dataBytes), m.Hash) {
+		return ErrInvalidHash
+	}
+
+	if m.SignatureScheme != protos.SignatureScheme_SIGNATURE_SCHEME_ED25519 {
+		return ErrInvalidSignatureScheme
+	}
+
+	if len(m.Signer) != ed25519.PublicKeySize {
+		return ErrInvalidSigner
+	}
+
+	if !ed25519.Verify(m.Signer, m.Hash, m.Signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Checks the structure of the data of a message: it must be intended for our network, not be too far in the
+// future & have a body matching its type.
+func ValidateMessageData(data *protos.MessageData, network protos.FarcasterNetwork) error {
+	if data.Fid == 0 {
+		return ErrMissingFid
+	}
+
+	if data.Network != network {
+		return fmt.Errorf("%w: %s (expected: %s)", ErrIncorrectNetwork, data.Network, network)
+	}
+
+	currentFcTime, err := fctime.GetFarcasterTime()
+	if err != nil {
+		return err
+	}
+	if int64(data.Timestamp)-currentFcTime > AllowedClockSkewSeconds {
+		return ErrTimestampInFuture
+	}
+
+	hasValidBody, ok := bodyTypes[data.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidMessageType, data.Type)
+	} else if !hasValidBody(data) {
+		return fmt.Errorf("%w: %s", ErrInvalidBodyType, data.Type)
+	}
+
+	return nil
+}
+
+// Runs every check a hub does on a message it receives: the structure of its data, then its hash & signature.
+func ValidateMessageForNetwork(m *protos.Message, network protos.FarcasterNetwork) error {
+	if err := DecodeData(m); err != nil {
+		return err
+	}
+	if m.Data == nil {
+		return ErrMissingData
+	}
+
+	if err := ValidateMessageData(m.Data, network); err != nil {
+		return err
+	}
+
+	return ValidateMessage(m)
+}
+
+var reasons = map[error]string{
+	ErrMissingData:            "missing_data",
+	ErrDataBytesTooLong:       "data_bytes_too_long",
+	ErrInvalidDataBytes:       "invalid_data_bytes",
+	ErrInvalidHashScheme:      "invalid_hash_scheme",
+	ErrInvalidHash:            "invalid_hash",
+	ErrInvalidSignatureScheme: "invalid_signature_scheme",
+	ErrInvalidSigner:          "invalid_signer",
+	ErrInvalidSignature:       "invalid_signature",
+	ErrMissingFid:             "missing_fid",
+	ErrIncorrectNetwork:       "incorrect_network",
+	ErrTimestampInFuture:      "timestamp_in_future",
+	ErrInvalidBodyType:        "invalid_body_type",
+	ErrInvalidMessageType:     "invalid_message_type",
+}
+
+// A short identifier of a validation error, suitable as a key to count rejections.
+func Reason(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	for knownErr, reason := range reasons {
+		if errors.Is(err, knownErr) {
+			return reason
+		}
+	}
+
+	return "unknown"
+}
+
+// Decodes data_bytes into data when a message only carries the former, so the handlers can read it.
+func DecodeData(m *protos.Message) error {
+	if m.Data != nil || m.DataBytes == nil {
+		return nil
+	}
+
+	data := new(protos.MessageData)
+	if err := proto.Unmarshal(m.DataBytes, data); err != nil {
+		return ErrInvalidDataBytes
+	}
+	m.Data = data
+
+	return nil
+}
+
+// Sets the data_bytes of the messages contained in a gossip message to their original serialization, read from
+// the raw gossip. Re-serializing the decoded MessageData doesn't always give back the bytes that were hashed because
+// other implementations don't write the fields in the same order, so this must be done before validating.
+func AttachDataBytes(raw []byte, gossip *protos.GossipMessage) error {
+	switch content := gossip.GetContent().(type) {
+	case *protos.GossipMessage_Message:
+		rawMessages, err := fields(raw, 1)
+		if err != nil || len(rawMessages) == 0 {
+			return err
+		}
+		return attach(rawMessages[len(rawMessages)-1], content.Message)
+	case *protos.GossipMessage_MessageBundle:
+		rawBundles, err := fields(raw, 9)
+		if err != nil || len(rawBundles) == 0 {
+			return err
+		}
+		rawMessages, err := fields(rawBundles[len(rawBundles)-1], 2)
+		if err != nil {
+			return err
+		}
+		messages := content.MessageBundle.GetMessages()
+		if len(rawMessages) != len(messages) {
+			return errors.New("the bundle doesn't contain the same number of messages once decoded")
+		}
+		for i, m := range messages {
+			if err := attach(rawMessages[i], m); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func attach(rawMessage []byte, m *protos.Message) error {
+	if m == nil || m.DataBytes != nil {
+		return nil
+	}
+
+	rawData, err := fields(rawMessage, 1)
+	if err != nil || len(rawData) == 0 {
+		return err
+	}
+
+	m.DataBytes = rawData[len(rawData)-1]
+	return nil
+}
+
+// Gets the values of every length-delimited field with the given number in a serialized message.
+func fields(raw []byte, number protowire.Number) ([][]byte, error) {
+	values := [][]byte{}
+	for len(raw) > 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		raw = raw[n:]
+
+		if num == number && typ == protowire.BytesType {
+			value, n := protowire.ConsumeBytes(raw)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			values = append(values, value)
+			raw = raw[n:]
+			continue
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, raw)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		raw = raw[n:]
+	}
+
+	return values, nil
+}
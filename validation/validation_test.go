@@ -0,0 +1,137 @@
+package validation_test
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	protos "github.com/noctisatrae/farseer/protos"
+	"github.com/noctisatrae/farseer/validation"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func signedCast(t *testing.T, text string) *protos.Message {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	data := &protos.MessageData{
+		Type:      protos.MessageType_MESSAGE_TYPE_CAST_ADD,
+		Fid:       10626,
+		Timestamp: 107778482,
+		Network:   protos.FarcasterNetwork_FARCASTER_NETWORK_MAINNET,
+		Body:      &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: text}},
+	}
+	dataBytes, err := proto.Marshal(data)
+	assert.NoError(t, err)
+
+	hash := validation.Hash(dataBytes)
+	return &protos.Message{
+		Data:            data,
+		Hash:            hash,
+		HashScheme:      protos.HashScheme_HASH_SCHEME_BLAKE3,
+		Signature:       ed25519.Sign(priv, hash),
+		SignatureScheme: protos.SignatureScheme_SIGNATURE_SCHEME_ED25519,
+		Signer:          pub,
+	}
+}
+
+func TestValidMessage(t *testing.T) {
+	m := signedCast(t, "gm")
+	assert.Len(t, m.Hash, validation.HashLength)
+	assert.NoError(t, validation.ValidateMessage(m))
+}
+
+func TestInvalidMessages(t *testing.T) {
+	cases := map[string]struct {
+		tamper func(m *protos.Message)
+		err    error
+	}{
+		"missing data":     {func(m *protos.Message) { m.Data = nil }, validation.ErrMissingData},
+		"hash scheme":      {func(m *protos.Message) { m.HashScheme = protos.HashScheme_HASH_SCHEME_NONE }, validation.ErrInvalidHashScheme},
+		"forged text":      {func(m *protos.Message) { m.Data.GetCastAddBody().Text = "gn" }, validation.ErrInvalidHash},
+		"signature scheme": {func(m *protos.Message) { m.SignatureScheme = protos.SignatureScheme_SIGNATURE_SCHEME_EIP712 }, validation.ErrInvalidSignatureScheme},
+		"short signer":     {func(m *protos.Message) { m.Signer = m.Signer[:16] }, validation.ErrInvalidSigner},
+		"other signer":     {func(m *protos.Message) { m.Signer = signedCast(t, "").Signer }, validation.ErrInvalidSignature},
+		"data bytes":       {func(m *protos.Message) { m.DataBytes = []byte{0xff} }, validation.ErrInvalidDataBytes},
+	}
+
+	for name, c := range cases {
+		m := signedCast(t, "gm")
+		c.tamper(m)
+
+		err := validation.ValidateMessage(m)
+		assert.ErrorIs(t, err, c.err, name)
+		assert.NotEqual(t, "unknown", validation.Reason(err), name)
+	}
+}
+
+// Messages serialized by other implementations don't always have their fields in the same order as Go: the hash
+// must be checked against the bytes found in the gossip.
+func TestAttachDataBytes(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	// fid before type: proto.Marshal would write them the other way around
+	dataBytes := protowire.AppendTag(nil, 2, protowire.VarintType)
+	dataBytes = protowire.AppendVarint(dataBytes, 10626)
+	dataBytes = protowire.AppendTag(dataBytes, 1, protowire.VarintType)
+	dataBytes = protowire.AppendVarint(dataBytes, uint64(protos.MessageType_MESSAGE_TYPE_CAST_ADD))
+	hash := validation.Hash(dataBytes)
+
+	rawMessage := protowire.AppendTag(nil, 1, protowire.BytesType)
+	rawMessage = protowire.AppendBytes(rawMessage, dataBytes)
+	rawMessage = protowire.AppendTag(rawMessage, 2, protowire.BytesType)
+	rawMessage = protowire.AppendBytes(rawMessage, hash)
+	rawMessage = protowire.AppendTag(rawMessage, 3, protowire.VarintType)
+	rawMessage = protowire.AppendVarint(rawMessage, uint64(protos.HashScheme_HASH_SCHEME_BLAKE3))
+	rawMessage = protowire.AppendTag(rawMessage, 4, protowire.BytesType)
+	rawMessage = protowire.AppendBytes(rawMessage, ed25519.Sign(priv, hash))
+	rawMessage = protowire.AppendTag(rawMessage, 5, protowire.VarintType)
+	rawMessage = protowire.AppendVarint(rawMessage, uint64(protos.SignatureScheme_SIGNATURE_SCHEME_ED25519))
+	rawMessage = protowire.AppendTag(rawMessage, 6, protowire.BytesType)
+	rawMessage = protowire.AppendBytes(rawMessage, pub)
+
+	rawBundle := protowire.AppendTag(nil, 1, protowire.BytesType)
+	rawBundle = protowire.AppendBytes(rawBundle, []byte{1, 2})
+	rawBundle = protowire.AppendTag(rawBundle, 2, protowire.BytesType)
+	rawBundle = protowire.AppendBytes(rawBundle, rawMessage)
+
+	rawGossip := protowire.AppendTag(nil, 9, protowire.BytesType)
+	rawGossip = protowire.AppendBytes(rawGossip, rawBundle)
+
+	gossip := new(protos.GossipMessage)
+	assert.NoError(t, proto.Unmarshal(rawGossip, gossip))
+
+	m := gossip.GetMessageBundle().GetMessages()[0]
+	assert.ErrorIs(t, validation.ValidateMessage(m), validation.ErrInvalidHash)
+
+	assert.NoError(t, validation.AttachDataBytes(rawGossip, gossip))
+	assert.Equal(t, dataBytes, m.DataBytes)
+	assert.NoError(t, validation.ValidateMessage(m))
+}
+
+func TestDecodeData(t *testing.T) {
+	m := signedCast(t, "gm")
+	dataBytes, err := proto.Marshal(m.Data)
+	assert.NoError(t, err)
+
+	onlyBytes := &protos.Message{DataBytes: dataBytes}
+	assert.NoError(t, validation.DecodeData(onlyBytes))
+	assert.Equal(t, "gm", onlyBytes.GetData().GetCastAddBody().GetText())
+}
+
+func TestValidateMessageData(t *testing.T) {
+	m := signedCast(t, "gm")
+	mainnet := protos.FarcasterNetwork_FARCASTER_NETWORK_MAINNET
+
+	assert.NoError(t, validation.ValidateMessageForNetwork(m, mainnet))
+	assert.ErrorIs(t, validation.ValidateMessageData(m.Data, protos.FarcasterNetwork_FARCASTER_NETWORK_TESTNET), validation.ErrIncorrectNetwork)
+
+	m.Data.Type = protos.MessageType_MESSAGE_TYPE_LINK_ADD
+	assert.ErrorIs(t, validation.ValidateMessageData(m.Data, mainnet), validation.ErrInvalidBodyType)
+
+	m.Data.Type = protos.MessageType_MESSAGE_TYPE_NONE
+	assert.ErrorIs(t, validation.ValidateMessageData(m.Data, mainnet), validation.ErrInvalidMessageType)
+}
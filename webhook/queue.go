@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// A message waiting to be posted to an endpoint, saved in queue/<id>.json until it's delivered or dead-lettered.
+type delivery struct {
+	Id          string          `json:"id"`
+	Url         string          `json:"url"`
+	Hash        string          `json:"hash"`
+	Body        json.RawMessage `json:"body"`
+	Attempts    uint            `json:"attempts"`
+	CreatedAt   time.Time       `json:"created_at"`
+	NextAttempt time.Time       `json:"next_attempt"`
+	LastError   string          `json:"last_error,omitempty"`
+}
+
+// The deliveries not done yet are in <directory>/queue, the ones given up on in <directory>/dead. A delivery is
+// written to a temporary file then renamed, so a crash never leaves half of one behind.
+type diskQueue struct {
+	queueDir string
+	deadDir  string
+}
+
+// Creates the directories & reads the deliveries left by the previous run.
+func openQueue(directory string) (*diskQueue, []*delivery, error) {
+	q := &diskQueue{
+		queueDir: filepath.Join(directory, "queue"),
+		deadDir:  filepath.Join(directory, "dead"),
+	}
+	for _, dir := range []string{q.queueDir, q.deadDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(q.queueDir, "*.json"))
+	if err != nil {
+		return nil, nil, err
+	}
+	deliveries := []*delivery{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		d := &delivery{}
+		if err := json.Unmarshal(content, d); err != nil {
+			return nil, nil, fmt.Errorf("invalid delivery %s: %w", file, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return q, deliveries, nil
+}
+
+// Writes (or overwrites) the delivery in the queue.
+func (q *diskQueue) Save(d *delivery) error {
+	return writeAtomic(filepath.Join(q.queueDir, d.Id+".json"), d)
+}
+
+// Removes a delivered message from the queue.
+func (q *diskQueue) Remove(d *delivery) error {
+	err := os.Remove(filepath.Join(q.queueDir, d.Id+".json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Moves the delivery to the dead letters, where it stays until someone moves it back to the queue.
+func (q *diskQueue) DeadLetter(d *delivery) error {
+	if err := writeAtomic(filepath.Join(q.deadDir, d.Id+".json"), d); err != nil {
+		return err
+	}
+	return q.Remove(d)
+}
+
+func writeAtomic(path string, d *delivery) error {
+	content, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// The id of the delivery of a message to an endpoint: the same message is only queued once per endpoint.
+func deliveryId(hash string, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hash + "-" + hex.EncodeToString(sum[:6])
+}
+
+// The deliveries waiting for their next attempt, the earliest first.
+type dueHeap []*delivery
+
+func (h dueHeap) Len() int           { return len(h) }
+func (h dueHeap) Less(i, j int) bool { return h[i].NextAttempt.Before(h[j].NextAttempt) }
+func (h dueHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *dueHeap) Push(x any)        { *h = append(*h, x.(*delivery)) }
+func (h *dueHeap) Pop() any {
+	old := *h
+	d := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return d
+}
+
+// The next delivery due, without removing it.
+func (h dueHeap) Peek() *delivery {
+	if len(h) == 0 {
+		return nil
+	}
+	return h[0]
+}
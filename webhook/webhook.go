@@ -0,0 +1,473 @@
+package main
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	handler "github.com/noctisatrae/farseer/handlers"
+	protos "github.com/noctisatrae/farseer/protos"
+	utils "github.com/noctisatrae/farseer/utils"
+
+	"github.com/charmbracelet/log"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// HMAC-SHA256 of the body with the Secret of the endpoint, as sha256=<hex>.
+	SignatureHeader = "X-Farseer-Signature"
+	// The id of the delivery, the same for every attempt: use it to ignore a message you already received.
+	DeliveryHeader = "X-Farseer-Delivery"
+	// 1 for the first attempt, 2 for the first retry...
+	AttemptHeader = "X-Farseer-Attempt"
+)
+
+// Where the messages are posted & which ones. An endpoint receives a message when its type is in MessageTypes &
+// it's sent by one of the Fids or one of the Mentions is mentioned in it. An empty filter lets everything through.
+type Endpoint struct {
+	Url          string
+	Secret       string
+	Fids         []uint64
+	Mentions     []uint64
+	MessageTypes []protos.MessageType
+}
+
+// The [handlers.webhook] table of config.toml.
+//
+// ```toml
+// [handlers.webhook]
+// Directory = "webhook" # optional: where the deliveries waiting for a retry & the dead letters are saved
+// MaxAttempts = 10 # optional: a delivery is dead-lettered after failing this many times
+// MinBackoff = 1000 # optional: the delay before the first retry, doubled after every failure, in milliseconds
+// MaxBackoff = 600000 # optional: the longest delay between two retries, in milliseconds
+// Timeout = 10000 # optional: how long an endpoint has to answer, in milliseconds
+// Concurrency = 4 # optional: the number of deliveries sent at once
+// MaxQueued = 10000 # optional: once an endpoint has this many deliveries waiting, the new ones are dead-lettered
+//
+// [[handlers.webhook.Endpoints]]
+// Url = "https://example.com/farcaster" # required
+// Secret = "..." # optional: the key signing the payloads
+// Fids = [10626] # optional: the casts, reactions... of these users
+// Mentions = [10626] # optional: the casts mentioning these users
+// MessageTypes = [1] # optional: see the MessageType enum in message.proto
+// ```
+type Config struct {
+	Endpoints   []Endpoint
+	Directory   string
+	MaxAttempts uint
+	MinBackoff  uint
+	MaxBackoff  uint
+	Timeout     uint
+	Concurrency uint
+	MaxQueued   uint
+}
+
+// What's posted to the endpoints.
+type Payload struct {
+	Hash      string          `json:"hash"`
+	Fid       uint64          `json:"fid"`
+	Type      string          `json:"type"`
+	Timestamp uint32          `json:"timestamp"`
+	Message   json.RawMessage `json:"message"`
+}
+
+// The endpoint was removed from the config since the delivery was queued.
+var errUnknownEndpoint = errors.New("the endpoint isn't in the config anymore")
+
+// Posts the messages to HTTP endpoints. A delivery is saved on disk before it's attempted, then retried with an
+// exponential backoff until the endpoint answers with a 2xx, so it survives a restart of the plugin. After
+// MaxAttempts, it's moved to the dead letters.
+type Webhook struct {
+	config Config
+	ll     log.Logger
+	client *http.Client
+	queue  *diskQueue
+	// replaced in the tests
+	now func() time.Time
+
+	mu sync.Mutex
+	// every delivery not done yet, by id
+	pending map[string]*delivery
+	// the deliveries waiting for their next attempt, neither being saved nor in flight
+	due      dueHeap
+	inFlight map[string]bool
+	// the deliveries not done yet of every endpoint, by URL
+	queued map[string]uint
+	wake   chan struct{}
+	stopCh chan struct{}
+	done   chan struct{}
+	// cancels the requests still running when Close gives up waiting for them
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (w *Webhook) Name() string {
+	return "Webhook"
+}
+
+func (w *Webhook) Configure(raw []byte) error {
+	config := Config{
+		Directory:   "webhook",
+		MaxAttempts: 10,
+		MinBackoff:  1000,
+		MaxBackoff:  600000,
+		Timeout:     10000,
+		Concurrency: 4,
+		MaxQueued:   10000,
+	}
+	if err := handler.Decode(raw, &config); err != nil {
+		return err
+	}
+	if len(config.Endpoints) == 0 {
+		return errors.New("no Endpoints were provided, so there's nowhere to post the messages")
+	}
+	for _, endpoint := range config.Endpoints {
+		u, err := url.Parse(endpoint.Url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid Url %q, expected an http(s) URL", endpoint.Url)
+		}
+	}
+	if config.MaxAttempts == 0 || config.Concurrency == 0 || config.MinBackoff == 0 || config.MaxQueued == 0 {
+		return errors.New("MaxAttempts, MinBackoff, Concurrency & MaxQueued can't be 0")
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		return errors.New("MaxBackoff can't be less than MinBackoff")
+	}
+
+	w.config = config
+	return nil
+}
+
+// Reads the deliveries left by the previous run & starts sending them.
+func (w *Webhook) Init(ctx context.Context) error {
+	w.ll = *log.WithPrefix(w.Name())
+	if w.now == nil {
+		w.now = time.Now
+	}
+
+	queue, deliveries, err := openQueue(w.config.Directory)
+	if err != nil {
+		return err
+	}
+	w.queue = queue
+	w.client = &http.Client{Timeout: time.Duration(w.config.Timeout) * time.Millisecond}
+	w.pending = map[string]*delivery{}
+	w.due = dueHeap{}
+	w.inFlight = map[string]bool{}
+	w.queued = map[string]uint{}
+	for _, d := range deliveries {
+		w.pending[d.Id] = d
+		w.queued[d.Url]++
+		heap.Push(&w.due, d)
+	}
+	if len(deliveries) > 0 {
+		w.ll.Info("Resuming the deliveries of the previous run! |", "Deliveries", len(deliveries))
+	}
+
+	w.wake = make(chan struct{}, 1)
+	w.stopCh = make(chan struct{})
+	w.done = make(chan struct{})
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	go w.run()
+
+	return nil
+}
+
+// Waits for the deliveries being sent. The ones waiting for a retry stay on disk for the next run.
+func (w *Webhook) Close(ctx context.Context) error {
+	if w.stopCh == nil {
+		return nil
+	}
+	close(w.stopCh)
+
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		w.cancel()
+		<-w.done
+	}
+	w.cancel()
+	w.stopCh = nil
+	return nil
+}
+
+// Does the endpoint want this message?
+func (e Endpoint) Matches(m *protos.Message) bool {
+	if len(e.MessageTypes) > 0 && !slices.Contains(e.MessageTypes, m.Data.Type) {
+		return false
+	}
+	if len(e.Fids) == 0 && len(e.Mentions) == 0 {
+		return true
+	}
+	if slices.Contains(e.Fids, m.Data.Fid) {
+		return true
+	}
+	for _, mention := range m.Data.GetCastAddBody().GetMentions() {
+		if slices.Contains(e.Mentions, mention) {
+			return true
+		}
+	}
+	return false
+}
+
+// Queues a delivery for every endpoint wanting the message. It's returned once they're saved, they're sent in the
+// background. When an endpoint already has MaxQueued deliveries waiting, the new one is dead-lettered instead.
+func (w *Webhook) HandleMessage(ctx context.Context, m *protos.Message, meta handler.MessageMeta) error {
+	var body []byte
+	hash := utils.BytesToHex(m.Hash)
+
+	for _, endpoint := range w.config.Endpoints {
+		if !endpoint.Matches(m) {
+			continue
+		}
+
+		if body == nil {
+			message, err := protojson.Marshal(m)
+			if err != nil {
+				return err
+			}
+			body, err = json.Marshal(Payload{
+				Hash:      hash,
+				Fid:       m.Data.Fid,
+				Type:      m.Data.Type.String(),
+				Timestamp: m.Data.Timestamp,
+				Message:   message,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		now := w.now()
+		d := &delivery{
+			Id:          deliveryId(hash, endpoint.Url),
+			Url:         endpoint.Url,
+			Hash:        hash,
+			Body:        body,
+			CreatedAt:   now,
+			NextAttempt: now,
+		}
+
+		w.mu.Lock()
+		if _, queued := w.pending[d.Id]; queued {
+			// the relay sent this message again, the first delivery is enough
+			w.mu.Unlock()
+			continue
+		}
+		if w.queued[d.Url] >= w.config.MaxQueued {
+			w.mu.Unlock()
+			d.LastError = "too many deliveries queued for the endpoint"
+			w.ll.Error("Too many deliveries queued, dead-lettering the message! |", "Url", d.Url, "Hash", d.Hash, "MaxQueued", w.config.MaxQueued)
+			if err := w.queue.DeadLetter(d); err != nil {
+				return err
+			}
+			continue
+		}
+		// reserved while it's saved, so it isn't queued twice, but only sent once it's on disk
+		w.pending[d.Id] = d
+		w.queued[d.Url]++
+		w.mu.Unlock()
+
+		err := w.queue.Save(d)
+
+		w.mu.Lock()
+		if err != nil {
+			w.forget(d)
+			w.mu.Unlock()
+			return err
+		}
+		heap.Push(&w.due, d)
+		w.mu.Unlock()
+	}
+
+	w.notify()
+	return nil
+}
+
+func (w *Webhook) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Sends the deliveries when they're due, Concurrency at a time.
+func (w *Webhook) run() {
+	defer close(w.done)
+
+	var running sync.WaitGroup
+	defer running.Wait()
+
+	for {
+		wait := w.startDue(&running)
+		select {
+		case <-w.stopCh:
+			return
+		case <-w.wake:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Starts the deliveries that are due & returns how long to wait for the next one.
+func (w *Webhook) startDue(running *sync.WaitGroup) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	// woken up by notify anyway, when a delivery is queued or done
+	wait := time.Minute
+	for uint(len(w.inFlight)) < w.config.Concurrency {
+		d := w.due.Peek()
+		if d == nil {
+			break
+		}
+		if d.NextAttempt.After(now) {
+			wait = min(wait, d.NextAttempt.Sub(now))
+			break
+		}
+
+		heap.Pop(&w.due)
+		w.inFlight[d.Id] = true
+		running.Add(1)
+		go func(d *delivery) {
+			defer running.Done()
+			w.attempt(d)
+		}(d)
+	}
+	return wait
+}
+
+// Sends the delivery, then removes it from the queue, schedules a retry or dead-letters it. The delivery belongs to
+// this goroutine while it's in flight, so it's written to disk without holding the lock.
+func (w *Webhook) attempt(d *delivery) {
+	err := w.send(d)
+	defer w.notify()
+
+	d.Attempts++
+	if err == nil {
+		if err := w.queue.Remove(d); err != nil {
+			w.ll.Error("Couldn't remove a delivery from the queue! |", "Id", d.Id, "Error", err)
+		}
+		w.ll.Debug("Delivered! |", "Url", d.Url, "Hash", d.Hash, "Attempts", d.Attempts)
+		w.finish(d)
+		return
+	}
+
+	d.LastError = err.Error()
+	if d.Attempts >= w.config.MaxAttempts || errors.Is(err, errUnknownEndpoint) {
+		if err := w.queue.DeadLetter(d); err != nil {
+			w.ll.Error("Couldn't dead-letter a delivery! |", "Id", d.Id, "Error", err)
+		}
+		w.ll.Error("Giving up on a delivery! |", "Url", d.Url, "Hash", d.Hash, "Attempts", d.Attempts, "Error", err)
+		w.finish(d)
+		return
+	}
+
+	d.NextAttempt = w.now().Add(w.backoff(d.Attempts))
+	if err := w.queue.Save(d); err != nil {
+		w.ll.Error("Couldn't save a delivery! |", "Id", d.Id, "Error", err)
+	}
+	w.ll.Warn("Couldn't deliver, retrying later! |", "Url", d.Url, "Hash", d.Hash, "Attempts", d.Attempts, "NextAttempt", d.NextAttempt, "Error", err)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.inFlight, d.Id)
+	heap.Push(&w.due, d)
+}
+
+// The delivery was sent or given up on.
+func (w *Webhook) finish(d *delivery) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.inFlight, d.Id)
+	w.forget(d)
+}
+
+// Must be called with the lock held.
+func (w *Webhook) forget(d *delivery) {
+	delete(w.pending, d.Id)
+	w.queued[d.Url]--
+	if w.queued[d.Url] == 0 {
+		delete(w.queued, d.Url)
+	}
+}
+
+// The delay before the next attempt: MinBackoff, doubled after every failure, up to MaxBackoff.
+func (w *Webhook) backoff(attempts uint) time.Duration {
+	backoff := time.Duration(w.config.MinBackoff) * time.Millisecond
+	maxBackoff := time.Duration(w.config.MaxBackoff) * time.Millisecond
+	for i := uint(1); i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+// Posts the payload, signed with the Secret of the endpoint. Anything but a 2xx is a failure.
+func (w *Webhook) send(d *delivery) error {
+	i := slices.IndexFunc(w.config.Endpoints, func(e Endpoint) bool { return e.Url == d.Url })
+	if i == -1 {
+		return errUnknownEndpoint
+	}
+	endpoint := w.config.Endpoints[i]
+
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, d.Url, bytes.NewReader(d.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "farseer-webhook")
+	req.Header.Set(DeliveryHeader, d.Id)
+	req.Header.Set(AttemptHeader, strconv.FormatUint(uint64(d.Attempts+1), 10))
+	if endpoint.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(endpoint.Secret, d.Body))
+	}
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("the endpoint answered %s", res.Status)
+	}
+	return nil
+}
+
+// The signature of a payload, sha256=<hex of the HMAC-SHA256 of the body>. Compare it with hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// The types of messages at least one endpoint wants.
+func (w *Webhook) MessageTypes() []protos.MessageType {
+	messageTypes := []protos.MessageType{}
+	for _, endpoint := range w.config.Endpoints {
+		if len(endpoint.MessageTypes) == 0 {
+			return handler.AllMessageTypes()
+		}
+		messageTypes = append(messageTypes, endpoint.MessageTypes...)
+	}
+	slices.Sort(messageTypes)
+	return slices.Compact(messageTypes)
+}
+
+// Served by main, or looked up by the relay when the plugin is built as a shared library.
+var Plugin handler.Plugin = &Webhook{}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	handler "github.com/noctisatrae/farseer/handlers"
+	"github.com/noctisatrae/farseer/handlers/plugintest"
+	protos "github.com/noctisatrae/farseer/protos"
+
+	"github.com/charmbracelet/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// A request received by the test endpoint.
+type received struct {
+	header  http.Header
+	body    []byte
+	payload Payload
+}
+
+// An endpoint answering with the given statuses in turn, then 200.
+type testEndpoint struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []received
+}
+
+func newTestEndpoint(t *testing.T, statuses ...int) *testEndpoint {
+	e := &testEndpoint{statuses: statuses}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := received{header: r.Header, body: body}
+		json.Unmarshal(body, &req.payload)
+
+		e.mu.Lock()
+		e.requests = append(e.requests, req)
+		status := http.StatusOK
+		if len(e.statuses) > 0 {
+			status, e.statuses = e.statuses[0], e.statuses[1:]
+		}
+		e.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(e.Close)
+	return e
+}
+
+func (e *testEndpoint) Requests() []received {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]received{}, e.requests...)
+}
+
+// Configures & initializes the plugin, writing its queue in directory.
+func initPlugin(t *testing.T, directory string, config string) *Webhook {
+	log.SetLevel(log.DebugLevel)
+
+	w := &Webhook{}
+	plugintest.Start(t, w, "Directory = '"+directory+"'\nMinBackoff = 10\nMaxBackoff = 40\n"+config)
+
+	return w
+}
+
+func cast(hash byte, fid uint64, mentions ...uint64) *protos.Message {
+	return &protos.Message{
+		Hash: []byte{hash},
+		Data: &protos.MessageData{
+			Type:      protos.MessageType_MESSAGE_TYPE_CAST_ADD,
+			Fid:       fid,
+			Timestamp: 100,
+			Body:      &protos.MessageData_CastAddBody{CastAddBody: &protos.CastAddBody{Text: "gm", Mentions: mentions}},
+		},
+	}
+}
+
+func files(t *testing.T, directory string) []string {
+	names, err := filepath.Glob(filepath.Join(directory, "*.json"))
+	assert.NoError(t, err)
+	return names
+}
+
+// Are the messages posted to the endpoints wanting them, signed with their secret?
+func TestDelivery(t *testing.T) {
+	byFid := newTestEndpoint(t)
+	byMention := newTestEndpoint(t)
+	directory := t.TempDir()
+	w := initPlugin(t, directory, `
+[[Endpoints]]
+Url = '`+byFid.URL+`'
+Secret = 'hunter2'
+Fids = [10626]
+MessageTypes = [1]
+
+[[Endpoints]]
+Url = '`+byMention.URL+`'
+Mentions = [3]
+`)
+
+	ctx := context.Background()
+	assert.NoError(t, w.HandleMessage(ctx, cast(1, 10626), handler.MessageMeta{}))
+	assert.NoError(t, w.HandleMessage(ctx, cast(2, 5, 3), handler.MessageMeta{}))
+	assert.NoError(t, w.HandleMessage(ctx, cast(3, 5), handler.MessageMeta{}))
+
+	assert.Eventually(t, func() bool {
+		return len(byFid.Requests()) == 1 && len(byMention.Requests()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	req := byFid.Requests()[0]
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Equal(t, Sign("hunter2", req.body), req.header.Get(SignatureHeader))
+	assert.Equal(t, "1", req.header.Get(AttemptHeader))
+	assert.Equal(t, "0x01", req.payload.Hash)
+	assert.Equal(t, uint64(10626), req.payload.Fid)
+	assert.Equal(t, "MESSAGE_TYPE_CAST_ADD", req.payload.Type)
+	assert.Equal(t, uint32(100), req.payload.Timestamp)
+	assert.Contains(t, string(req.payload.Message), `"text":"gm"`)
+
+	req = byMention.Requests()[0]
+	assert.Equal(t, "0x02", req.payload.Hash)
+	assert.Empty(t, req.header.Get(SignatureHeader))
+
+	assert.Eventually(t, func() bool {
+		return len(files(t, filepath.Join(directory, "queue"))) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []protos.MessageType{
+		protos.MessageType_MESSAGE_TYPE_CAST_ADD, protos.MessageType_MESSAGE_TYPE_CAST_REMOVE,
+		protos.MessageType_MESSAGE_TYPE_REACTION_ADD, protos.MessageType_MESSAGE_TYPE_REACTION_REMOVE,
+		protos.MessageType_MESSAGE_TYPE_LINK_ADD, protos.MessageType_MESSAGE_TYPE_LINK_REMOVE,
+		protos.MessageType_MESSAGE_TYPE_VERIFICATION_ADD_ETH_ADDRESS, protos.MessageType_MESSAGE_TYPE_VERIFICATION_REMOVE,
+		protos.MessageType_MESSAGE_TYPE_USER_DATA_ADD, protos.MessageType_MESSAGE_TYPE_USERNAME_PROOF,
+		protos.MessageType_MESSAGE_TYPE_FRAME_ACTION, protos.MessageType_MESSAGE_TYPE_LINK_COMPACT_STATE,
+	}, w.MessageTypes())
+}
+
+// Is a failed delivery retried, then dead-lettered after MaxAttempts?
+func TestRetries(t *testing.T) {
+	flaky := newTestEndpoint(t, http.StatusInternalServerError, http.StatusServiceUnavailable)
+	down := newTestEndpoint(t, 500, 500, 500, 500)
+	directory := t.TempDir()
+	w := initPlugin(t, directory, `
+MaxAttempts = 3
+
+[[Endpoints]]
+Url = '`+flaky.URL+`'
+
+[[Endpoints]]
+Url = '`+down.URL+`'
+`)
+
+	assert.NoError(t, w.HandleMessage(context.Background(), cast(1, 10626), handler.MessageMeta{}))
+
+	deadLetters := filepath.Join(directory, "dead")
+	assert.Eventually(t, func() bool {
+		return len(flaky.Requests()) == 3 && len(files(t, deadLetters)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	requests := flaky.Requests()
+	assert.Equal(t, "3", requests[2].header.Get(AttemptHeader))
+	assert.Equal(t, requests[0].header.Get(DeliveryHeader), requests[2].header.Get(DeliveryHeader))
+	assert.Len(t, down.Requests(), 3)
+
+	content, err := os.ReadFile(files(t, deadLetters)[0])
+	assert.NoError(t, err)
+	var d delivery
+	assert.NoError(t, json.Unmarshal(content, &d))
+	assert.Equal(t, down.URL, d.Url)
+	assert.Equal(t, uint(3), d.Attempts)
+	assert.Contains(t, d.LastError, "500")
+	assert.Empty(t, files(t, filepath.Join(directory, "queue")))
+}
+
+// Are the deliveries waiting for a retry sent by the next run of the plugin?
+func TestQueueSurvivesRestart(t *testing.T) {
+	endpoint := newTestEndpoint(t, 503)
+	directory := t.TempDir()
+	config := `
+MinBackoff = 60000
+MaxBackoff = 60000
+
+[[Endpoints]]
+Url = '` + endpoint.URL + `'
+`
+	w := &Webhook{}
+	assert.NoError(t, w.Configure([]byte("Directory = '"+directory+"'\n"+config)))
+	assert.NoError(t, w.Init(context.Background()))
+
+	assert.NoError(t, w.HandleMessage(context.Background(), cast(1, 10626), handler.MessageMeta{}))
+	// redelivered by the relay, it's only queued once
+	assert.NoError(t, w.HandleMessage(context.Background(), cast(1, 10626), handler.MessageMeta{}))
+	assert.Eventually(t, func() bool { return len(endpoint.Requests()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, w.Close(context.Background()))
+	assert.Len(t, files(t, filepath.Join(directory, "queue")), 1)
+
+	// the retry isn't due yet, unless the clock moved forward
+	restarted := &Webhook{now: func() time.Time { return time.Now().Add(time.Hour) }}
+	assert.NoError(t, restarted.Configure([]byte("Directory = '"+directory+"'\n"+config)))
+	assert.NoError(t, restarted.Init(context.Background()))
+	t.Cleanup(func() { restarted.Close(context.Background()) })
+
+	assert.Eventually(t, func() bool {
+		return len(endpoint.Requests()) == 2 && len(files(t, filepath.Join(directory, "queue"))) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "2", endpoint.Requests()[1].header.Get(AttemptHeader))
+}
+
+// Are the deliveries dead-lettered once an endpoint has MaxQueued of them waiting?
+func TestMaxQueued(t *testing.T) {
+	down := newTestEndpoint(t, 500, 500, 500, 500, 500, 500)
+	directory := t.TempDir()
+	w := initPlugin(t, directory, `
+MaxQueued = 2
+
+[[Endpoints]]
+Url = '`+down.URL+`'
+`)
+
+	for hash := byte(1); hash <= 3; hash++ {
+		assert.NoError(t, w.HandleMessage(context.Background(), cast(hash, 10626), handler.MessageMeta{}))
+	}
+	// the endpoint comes back up: the deliveries that were queued are sent
+	assert.Eventually(t, func() bool {
+		return len(down.Requests()) == 8 && len(files(t, filepath.Join(directory, "queue"))) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	deadLetters := files(t, filepath.Join(directory, "dead"))
+	assert.Len(t, deadLetters, 1)
+	content, err := os.ReadFile(deadLetters[0])
+	assert.NoError(t, err)
+	var d delivery
+	assert.NoError(t, json.Unmarshal(content, &d))
+	assert.Equal(t, "0x03", d.Hash)
+	assert.Equal(t, uint(0), d.Attempts)
+	assert.Contains(t, d.LastError, "too many deliveries")
+}
+
+// Are the deliveries taken by their next attempt, the earliest first?
+func TestDueHeap(t *testing.T) {
+	now := time.Now()
+	due := &dueHeap{}
+	for _, offset := range []int{3, 1, 2} {
+		heap.Push(due, &delivery{Id: strconv.Itoa(offset), NextAttempt: now.Add(time.Duration(offset) * time.Second)})
+	}
+	assert.Equal(t, "1", due.Peek().Id)
+
+	order := []string{}
+	for due.Len() > 0 {
+		order = append(order, heap.Pop(due).(*delivery).Id)
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, order)
+	assert.Nil(t, due.Peek())
+}
+
+// Is the delay doubled after every failure, up to MaxBackoff?
+func TestBackoff(t *testing.T) {
+	w := &Webhook{config: Config{MinBackoff: 1000, MaxBackoff: 5000}}
+	assert.Equal(t, time.Second, w.backoff(1))
+	assert.Equal(t, 2*time.Second, w.backoff(2))
+	assert.Equal(t, 4*time.Second, w.backoff(3))
+	assert.Equal(t, 5*time.Second, w.backoff(4))
+	assert.Equal(t, 5*time.Second, w.backoff(40))
+}
+
+// Are the invalid configs refused?
+func TestConfigure(t *testing.T) {
+	w := &Webhook{}
+	assert.Error(t, w.Configure([]byte("")))
+	assert.Error(t, w.Configure([]byte("[[Endpoints]]\nUrl = 'ftp://example.com'")))
+	assert.Error(t, w.Configure([]byte("MaxAttempts = 0\n[[Endpoints]]\nUrl = 'https://example.com'")))
+	assert.Error(t, w.Configure([]byte("MaxBackoff = 10\n[[Endpoints]]\nUrl = 'https://example.com'")))
+	assert.Error(t, w.Configure([]byte("MaxQueued = 0\n[[Endpoints]]\nUrl = 'https://example.com'")))
+	assert.NoError(t, w.Configure([]byte("[[Endpoints]]\nUrl = 'https://example.com'\nFids = [10626]")))
+}